@@ -0,0 +1,255 @@
+package main
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CertificateTemplateFileName overrides the built-in certificate template.
+const CertificateTemplateFileName = "certificate.html.tmpl"
+
+// MemberHours is the volunteer record of a member for a year.
+type MemberHours struct {
+	Member           Member
+	Checkins         int
+	NetsRun          int
+	HospitalStations int
+	// Hours are hours of running nets and staffing hospital stations.
+	Hours float64
+}
+
+// CertificateData is available to the certificate template.
+type CertificateData struct {
+	Station Station
+	Year    int
+	MemberHours
+}
+
+const defaultCertificateTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="UTF-8">
+<title>Certificate of Appreciation {{.Year}} {{.Member.Callsign}}</title>
+<style>
+body { font-family: serif; text-align: center; margin: 4em; }
+h1 { font-size: 3em; }
+.name { font-size: 2em; font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>Certificate of Appreciation</h1>
+<p>is presented to</p>
+<p class="name">{{.Member.Name}} {{.Member.Callsign}}</p>
+<p>for volunteering {{printf "%0.1f" .Hours}} hours in {{.Year}}.</p>
+<p>Nets run: {{.NetsRun}}. Hospital stations staffed: {{.HospitalStations}}. Net checkins: {{.Checkins}}.</p>
+<p>{{.Station.Signature}}</p>
+</body>
+</html>
+`
+
+// MemberTally counts member checkins by call sign.
+type MemberTally struct {
+	checkins map[string]int
+}
+
+func (c *MemberTally) visitDup(d *DupCheckin) {
+}
+
+func (c *MemberTally) visitMember(m *MemberCheckin) {
+	c.checkins[m.s]++
+}
+
+func (c *MemberTally) visitSection() {
+}
+
+func (c *MemberTally) visitUnknown(u *UnknownCheckin) {
+}
+
+// yearLedger walks net logs, hospital logs and the net control schedule of
+// the year and returns volunteer records of members sorted by hours. Nets
+// after now are not counted.
+func yearLedger(year int, now time.Time, config *Config, callsignDB map[string]Member, ncSchedule []NetcontrolScheduleRecord) ([]MemberHours, error) {
+	ledger := make(map[string]*MemberHours)
+	entry := func(callsign string) *MemberHours {
+		e, ok := ledger[callsign]
+		if !ok {
+			member, ok := callsignDB[callsign]
+			if !ok {
+				member = Member{Callsign: callsign}
+			}
+			e = &MemberHours{Member: member}
+			ledger[callsign] = e
+		}
+		return e
+	}
+	yearPrefix := fmt.Sprintf("%d-", year)
+
+	netLogs, err := filepath.Glob(filepath.Join(config.NetDir, yearPrefix) + "*")
+	if err != nil {
+		return nil, err
+	}
+	netHours := make(map[string]float64)
+	for _, f := range netLogs {
+		records, err := readCheckinRecords(f)
+		if err != nil {
+			return nil, err
+		}
+		tally := &MemberTally{make(map[string]int)}
+		for c := range annotateCheckins(callsignDB, recordChan(records)) {
+			c.accept(tally)
+		}
+		checkins := 0
+		for callsign, n := range tally.checkins {
+			entry(callsign).Checkins += n
+			checkins += n
+		}
+		netHours[logDate(f)], _ = netLogHours(records, checkins, config.Hours.net())
+	}
+
+	for _, r := range ncSchedule {
+		if r.Date.Year() != year || r.Date.After(now) {
+			continue
+		}
+		callsign := r.Callsign
+		if r.Confirmation == ConfirmationDeclined {
+			callsign = r.Alternate
+		}
+		if callsign == "" {
+			continue
+		}
+		e := entry(callsign)
+		e.NetsRun++
+		e.Hours += netHours[r.Date.Format("2006-01-02")]
+	}
+
+	if config.HospitalDir != "" {
+		hospitalLogs, err := filepath.Glob(filepath.Join(config.HospitalDir, yearPrefix) + "*")
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range hospitalLogs {
+			assignments, err := readHospitalLog(f, callsignDB)
+			if err != nil {
+				fmt.Printf("Skipping hospital log %v: %v\n", f, err)
+				continue
+			}
+			records, err := readCheckinRecords(f)
+			if err != nil {
+				return nil, err
+			}
+			_, operators, _ := hospitalLogHours(records, config.Hours.hospital())
+			// Operators may be logged with tactical call signs.
+			memberHours := make(map[string]float64)
+			for callsign, hours := range operators {
+				if m, ok := lookupMember(callsignDB, callsign); ok {
+					memberHours[m.Callsign] += hours
+				}
+			}
+			for _, a := range assignments {
+				e := entry(a.Member.Callsign)
+				e.HospitalStations++
+				e.Hours += memberHours[a.Member.Callsign]
+				// Hours of an operator with several stations are counted once.
+				delete(memberHours, a.Member.Callsign)
+			}
+		}
+	}
+
+	res := make([]MemberHours, 0, len(ledger))
+	for _, e := range ledger {
+		res = append(res, *e)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Hours != res[j].Hours {
+			return res[i].Hours > res[j].Hours
+		}
+		return res[i].Member.Callsign < res[j].Member.Callsign
+	})
+	return res, nil
+}
+
+// logDate returns the date part of a log file name like 2022-09-13.txt.
+func logDate(fileName string) string {
+	base := filepath.Base(fileName)
+	if len(base) < len("2006-01-02") {
+		return base
+	}
+	return base[:len("2006-01-02")]
+}
+
+func formatYearLedger(ledger []MemberHours) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Call sign\tName\tCheckins\tNets\tHospitals\tHours\n")
+	for _, e := range ledger {
+		fmt.Fprintf(&sb, "%v\t%v\t%d\t%d\t%d\t%0.3f\n", e.Member.Callsign, e.Member.Name, e.Checkins, e.NetsRun, e.HospitalStations, e.Hours)
+	}
+	return sb.String()
+}
+
+// writeCertificates writes html certificates of members with at least
+// minHours hours to the directory and returns their file names.
+func writeCertificates(dir string, year int, ledger []MemberHours, minHours float64, station Station) ([]string, error) {
+	text, err := templateText(CertificateTemplateFileName, defaultCertificateTemplate)
+	if err != nil {
+		return nil, err
+	}
+	t, err := htmltemplate.New("certificate").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse certificate template: %w", err)
+	}
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create certificate directory: %w", err)
+	}
+	files := make([]string, 0)
+	for _, e := range ledger {
+		if e.Hours < minHours || e.Hours == 0 {
+			continue
+		}
+		fileName := filepath.Join(dir, fmt.Sprintf("%d-%v.html", year, strings.ReplaceAll(e.Member.Callsign, "/", "-")))
+		f, err := os.Create(fileName)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to create certificate: %w", err)
+		}
+		err = t.Execute(f, CertificateData{station, year, e})
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("Failed to render certificate of %v: %w", e.Member.Callsign, err)
+		}
+		files = append(files, fileName)
+	}
+	return files, nil
+}
+
+// yearReport prints the volunteer ledger of the year and writes
+// certificates if the directory is specified.
+func yearReport(year int, now time.Time, config *Config, callsignDB map[string]Member, certificateDir string, minHours float64) error {
+	if config == nil {
+		return fmt.Errorf("Volunteer hours require a config file with net-log-directory")
+	}
+	ncSchedule, err := readNetcontrolSchedule()
+	if err != nil {
+		return fmt.Errorf("Failed to parse net control schedule: %w", err)
+	}
+	ledger, err := yearLedger(year, now, config, callsignDB, ncSchedule)
+	if err != nil {
+		return err
+	}
+	fmt.Print(formatYearLedger(ledger))
+	if certificateDir == "" {
+		return nil
+	}
+	files, err := writeCertificates(certificateDir, year, ledger, minHours, config.Station)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		fmt.Printf("Certificate: %v\n", f)
+	}
+	return nil
+}
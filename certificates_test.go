@@ -0,0 +1,52 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestYearLedger(t *testing.T) {
+	netDir := t.TempDir()
+	hospitalDir := t.TempDir()
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(netDir, "2022-10-04.txt"), []byte("19:30\nN6DVS\nK4LXF4\nK4LXF4\n20:30\n"), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(netDir, "2022-10-11.txt"), []byte("KJ6ABC\nK4LXF4\nXX1XX\n"), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(netDir, "2021-10-11.txt"), []byte("KJ6ABC\n"), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(hospitalDir, "2022-10-26.txt"), []byte("GSH K4LXF4/M\nOCH KJ6ABC\n"), 0644))
+	ncSchedule := []NetcontrolScheduleRecord{
+		{Date: time.Date(2022, 10, 4, 0, 0, 0, 0, time.Local), Callsign: "N6DVS"},
+		{Date: time.Date(2022, 10, 11, 0, 0, 0, 0, time.Local), Callsign: "N6DVS", Confirmation: ConfirmationDeclined, Alternate: "KJ6ABC"},
+		{Date: time.Date(2022, 12, 27, 0, 0, 0, 0, time.Local), Callsign: "K4LXF4"},
+	}
+	config := &Config{NetDir: netDir, HospitalDir: hospitalDir}
+	config.Hours.Net = &HoursPolicy{PerCheckin: 0.25, Prep: 0.5, Reporting: 0.25}
+	now := time.Date(2022, 11, 1, 0, 0, 0, 0, time.Local)
+
+	ledger, err := yearLedger(2022, now, config, testSignupCallsigns(), ncSchedule)
+	assert.Nil(t, err)
+	callsigns := testSignupCallsigns()
+	assert.Equal(t, []MemberHours{
+		{callsigns["KJ6ABC"], 1, 1, 1, 1.75},
+		{callsigns["N6DVS"], 1, 1, 0, 1.75},
+		{callsigns["K4LXF4"], 2, 0, 1, 0.5},
+	}, ledger)
+
+	dir := filepath.Join(t.TempDir(), "certificates")
+	files, err := writeCertificates(dir, 2022, ledger, 1, Station{Signature: "N6DVS"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "2022-KJ6ABC.html"), filepath.Join(dir, "2022-N6DVS.html")}, files)
+	data, err := ioutil.ReadFile(files[0])
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), "Lily KJ6ABC")
+	_, err = os.Stat(filepath.Join(dir, "2022-K4LXF4.html"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestYearReportWithoutConfig(t *testing.T) {
+	err := yearReport(2022, time.Now(), nil, nil, "", 10)
+	assert.NotNil(t, err)
+}
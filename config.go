@@ -17,10 +17,23 @@ type Config struct {
 	NetDir      string  `yaml:"net-log-directory"`
 	HospitalDir string  `yaml:"hospital-log-directory"`
 	MailingList string  `yaml:"mailing-list"`
+	BackupList  string  `yaml:"backup-list"`
 	TimeReport  struct {
 		MainMail string `yaml:"main-mail"`
 		CcMail   string `yaml:"cc-mail"`
 	} `yaml:"time-report"`
+	NetTime         NetTime      `yaml:"net-time"`
+	HospitalNetTime NetTime      `yaml:"hospital-net-time"`
+	Daemon          DaemonConfig `yaml:"daemon"`
+	Rules           []Rule       `yaml:"rules"`
+	// SubstitutePool are call signs of net controls that are asked to run
+	// open nets.
+	SubstitutePool []string        `yaml:"substitute-pool"`
+	Reminders      []ReminderStage `yaml:"reminders"`
+	Hours          HoursConfig     `yaml:"hours"`
+	// TacticalCallsigns map tactical call signs like GSH NET to call signs
+	// of their operators.
+	TacticalCallsigns map[string]string `yaml:"tactical-callsigns"`
 }
 
 type Station struct {
@@ -34,10 +47,13 @@ type Mail struct {
 	Port     int    `yaml:"port"`
 	Password string `yaml:"password"`
 	Email    string `yaml:"email"`
+	// Transport is either smtp (default) or outbox.
+	Transport string `yaml:"transport"`
+	OutboxDir string `yaml:"outbox-directory"`
 }
 
 func parseConfig(data []byte) (*Config, error) {
-	config := &Config{}
+	config := &Config{Hours: defaultHoursConfig()}
 	err := yaml.Unmarshal(data, &config)
 	if err != nil {
 		return nil, err
@@ -65,6 +81,31 @@ workingDir:
 	return f, err
 }
 
+// configFilePath returns the path of a file that net manager maintains
+// itself in the configuration directory.
+func configFilePath(fileName string) string {
+	userHomeDir, err := os.UserHomeDir()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to find user home directory: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Using the working directory for %v\n", fileName)
+		return fileName
+	}
+	return filepath.Join(userHomeDir, configDir, fileName)
+}
+
+// configPath returns the path of the file in the configuration directory if
+// it exists there, otherwise the fallback path in the working directory.
+func configPath(fileName, fallback string) string {
+	userHomeDir, err := os.UserHomeDir()
+	if err == nil {
+		path := filepath.Join(userHomeDir, configDir, fileName)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return fallback
+}
+
 func readConfig() (config *Config) {
 	userHomeDir, err := os.UserHomeDir()
 	var data []byte
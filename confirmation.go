@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"gopkg.in/gomail.v2"
+)
+
+// Confirmation states of net control schedule records.
+const (
+	ConfirmationPending   = "pending"
+	ConfirmationConfirmed = "confirmed"
+	ConfirmationDeclined  = "declined"
+)
+
+func parseConfirmation(s string) (string, error) {
+	switch strings.ToLower(s) {
+	case "":
+		return ConfirmationPending, nil
+	case ConfirmationPending, ConfirmationConfirmed, ConfirmationDeclined:
+		return strings.ToLower(s), nil
+	}
+	return "", fmt.Errorf("Unknown confirmation state: %v", s)
+}
+
+// markNetControl sets the confirmation state of the net control record on
+// the date in the net control schedule file.
+func markNetControl(date time.Time, confirmation string) error {
+	confirmation, err := parseConfirmation(confirmation)
+	if err != nil {
+		return err
+	}
+	fileName := configPath(NetcontrolScheduleFileName, NetcontrolScheduleFileName)
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return fmt.Errorf("Failed to read net control schedule: %w", err)
+	}
+	lines := strings.Split(string(data), "\n")
+	found := false
+	for i, line := range lines {
+		tokens := strings.Split(line, "\t")
+		recordDate, err := time.Parse("1/2/2006", strings.TrimSpace(tokens[0]))
+		if err != nil || !equalByDate(recordDate, date) {
+			continue
+		}
+		for len(tokens) < 3 {
+			tokens = append(tokens, "")
+		}
+		tokens[2] = confirmation
+		lines[i] = strings.Join(tokens, "\t")
+		found = true
+	}
+	if !found {
+		return fmt.Errorf("No net control scheduled on %v", date.Format("1/2/2006"))
+	}
+	err = ioutil.WriteFile(fileName, []byte(strings.Join(lines, "\n")), 0644)
+	if err != nil {
+		return fmt.Errorf("Failed to write net control schedule: %w", err)
+	}
+	return nil
+}
+
+// escalateNetControl emails the net manager and the backup list if the net
+// control of the net on netDate hasn't confirmed it.
+func escalateNetControl(netDate time.Time, callsignDB map[string]Member, config *Config, mailer Mailer, ncSchedule []NetcontrolScheduleRecord) error {
+	var record NetcontrolScheduleRecord
+	for _, nr := range ncSchedule {
+		if equalByDate(nr.Date, netDate) {
+			record = nr
+		}
+	}
+	if record.Callsign == "" {
+		fmt.Printf("No net control scheduled on %v\n", netDate.Format("1/2/2006"))
+		return nil
+	}
+	if record.Confirmation == ConfirmationConfirmed {
+		fmt.Printf("Net control %v confirmed the net on %v\n", record.Callsign, netDate.Format("1/2/2006"))
+		return nil
+	}
+	ncCallsign := strings.ToUpper(record.Callsign)
+	member, ok := callsignDB[ncCallsign]
+	if !ok {
+		member = Member{Callsign: ncCallsign}
+	}
+
+	m := gomail.NewMessage()
+	m.SetHeader("From", config.Station.Mail.Email)
+	m.SetHeader("To", config.Station.Mail.Email)
+	if config.BackupList != "" {
+		m.SetHeader("Cc", config.BackupList)
+	}
+	m.SetHeader("Subject", fmt.Sprintf("Net control not confirmed %v: %v", netDate.Format("1/2/2006"), ncCallsign))
+	err := setBody(m, NetControlEscalationKind, MessageData{
+		Station:      config.Station,
+		Month:        time.Date(netDate.Year(), netDate.Month(), 1, 0, 0, 0, 0, netDate.Location()),
+		Member:       member,
+		NetDate:      netDate,
+		Confirmation: record.Confirmation,
+	})
+	if err != nil {
+		return err
+	}
+	if err := mailer.Send(m); err != nil {
+		return fmt.Errorf("Failed to send email: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarkNetControl(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	configDir := filepath.Join(home, ".net-manager")
+	assert.Nil(t, os.MkdirAll(configDir, 0755))
+	fileName := filepath.Join(configDir, NetcontrolScheduleFileName)
+	err := ioutil.WriteFile(fileName, []byte("10/4/2022\tK4LXF4\n10/11/2022\tN6DVS\tpending\tKJ6ABC\n"), 0644)
+	assert.Nil(t, err)
+
+	assert.Nil(t, markNetControl(time.Date(2022, 10, 4, 0, 0, 0, 0, time.Local), ConfirmationConfirmed))
+	assert.Nil(t, markNetControl(time.Date(2022, 10, 11, 0, 0, 0, 0, time.Local), "Declined"))
+	assert.NotNil(t, markNetControl(time.Date(2022, 10, 18, 0, 0, 0, 0, time.Local), ConfirmationConfirmed))
+	assert.NotNil(t, markNetControl(time.Date(2022, 10, 4, 0, 0, 0, 0, time.Local), "maybe"))
+
+	data, err := ioutil.ReadFile(fileName)
+	assert.Nil(t, err)
+	assert.Equal(t, "10/4/2022\tK4LXF4\tconfirmed\n10/11/2022\tN6DVS\tdeclined\tKJ6ABC\n", string(data))
+	schedule, err := readNetcontrolSchedule()
+	assert.Nil(t, err)
+	assert.Equal(t, ConfirmationConfirmed, schedule[0].Confirmation)
+	assert.Equal(t, ConfirmationDeclined, schedule[1].Confirmation)
+	assert.Equal(t, "KJ6ABC", schedule[1].Alternate)
+}
+
+func TestEscalationRuleFiresBeforeNet(t *testing.T) {
+	rule := Rule{Message: NetControlEscalationKind, Trigger: BeforeNetTrigger, Offset: 1}
+	assert.Nil(t, rule.validate())
+	tuesday := time.Date(2022, 10, 11, 0, 0, 0, 0, time.Local)
+	wednesday := time.Date(2022, 10, 12, 0, 0, 0, 0, time.Local)
+	// The net is moved to Wednesday.
+	schedule := []NetcontrolScheduleRecord{
+		{Date: time.Date(2022, 10, 4, 0, 0, 0, 0, time.Local), Callsign: "K4LXF4"},
+		{Date: wednesday, Callsign: "K4LXF4"},
+	}
+	ledger := &Ledger{DryRun: true}
+	assert.Equal(t, []RuleEvent{{tuesday, wednesday}}, rule.events(tuesday.Add(8*time.Hour), ledger, schedule))
+	assert.Equal(t, []RuleEvent{{wednesday, wednesday}}, rule.events(wednesday.Add(8*time.Hour), ledger, schedule))
+	assert.Equal(t, 0, len(rule.events(time.Date(2022, 10, 10, 8, 0, 0, 0, time.Local), ledger, schedule)))
+	assert.NotNil(t, Rule{Message: NetControlEscalationKind, Trigger: BeforeNetTrigger, Offset: -1}.validate())
+}
+
+func TestEscalateNetControl(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	callsigns := map[string]Member{"K4LXF4": Member{"Herman", "K4LXF4", "herman@munster.com"}}
+	config := &Config{BackupList: "backup@example.com"}
+	config.Station.Mail.Email = "manager@example.com"
+	netDate := time.Date(2022, 10, 11, 0, 0, 0, 0, time.Local)
+	schedule := []NetcontrolScheduleRecord{
+		{Date: netDate, Callsign: "K4LXF4", Confirmation: ConfirmationConfirmed},
+	}
+	mailer := &RecordingMailer{}
+
+	assert.Nil(t, escalateNetControl(netDate, callsigns, config, mailer, schedule))
+	assert.Equal(t, 0, len(mailer.Messages))
+
+	schedule[0].Confirmation = ConfirmationPending
+	assert.Nil(t, escalateNetControl(netDate, callsigns, config, mailer, schedule))
+	assert.Equal(t, 1, len(mailer.Messages))
+	assert.Equal(t, []string{"manager@example.com"}, mailer.Messages[0].GetHeader("To"))
+	assert.Equal(t, []string{"backup@example.com"}, mailer.Messages[0].GetHeader("Cc"))
+	assert.Equal(t, []string{"Net control not confirmed 10/11/2022: K4LXF4"}, mailer.Messages[0].GetHeader("Subject"))
+}
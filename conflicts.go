@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// netControlConflicts reports dates with more than one net control.
+func netControlConflicts(ncSchedule []NetcontrolScheduleRecord) (conflicts []string) {
+	callsigns := make(map[string][]string)
+	dates := make([]string, 0)
+	for _, nr := range ncSchedule {
+		date := netDateString(nr.Date)
+		if _, ok := callsigns[date]; !ok {
+			dates = append(dates, date)
+		}
+		callsigns[date] = append(callsigns[date], strings.ToUpper(nr.Callsign))
+	}
+	sort.Strings(dates)
+	for _, date := range dates {
+		if len(callsigns[date]) > 1 {
+			conflicts = append(conflicts, fmt.Sprintf("Net %v is double booked: %v", date, strings.Join(callsigns[date], ", ")))
+		}
+	}
+	return
+}
+
+// hospitalConflicts reports hospitals with more than one operator and
+// operators holding more than one hospital in the same hospital net.
+func hospitalConflicts(netDate time.Time, assignments []HospitalAssignment) (conflicts []string) {
+	operators := make(map[string][]string)
+	hospitals := make(map[string][]string)
+	var acronyms, callsigns []string
+	for _, a := range assignments {
+		if _, ok := operators[a.Acronym]; !ok {
+			acronyms = append(acronyms, a.Acronym)
+		}
+		operators[a.Acronym] = append(operators[a.Acronym], a.Member.Callsign)
+		if _, ok := hospitals[a.Member.Callsign]; !ok {
+			callsigns = append(callsigns, a.Member.Callsign)
+		}
+		hospitals[a.Member.Callsign] = append(hospitals[a.Member.Callsign], a.Acronym)
+	}
+	date := netDateString(netDate)
+	for _, acronym := range acronyms {
+		if len(operators[acronym]) > 1 {
+			conflicts = append(conflicts, fmt.Sprintf("Hospital %v on %v is double booked: %v", acronym, date, strings.Join(operators[acronym], ", ")))
+		}
+	}
+	for _, callsign := range callsigns {
+		if len(hospitals[callsign]) > 1 {
+			conflicts = append(conflicts, fmt.Sprintf("Operator %v holds more than one hospital on %v: %v", callsign, date, strings.Join(hospitals[callsign], ", ")))
+		}
+	}
+	return
+}
+
+// scheduleConflicts validates net control records and hospital net logs in
+// hospitalDir whose dates start with the prefix. An empty prefix validates
+// the whole schedule, an empty hospitalDir skips hospital nets.
+func scheduleConflicts(prefix string, ncSchedule []NetcontrolScheduleRecord, hospitalDir string, callsignDB map[string]Member) ([]string, error) {
+	ncRecords := make([]NetcontrolScheduleRecord, 0)
+	for _, nr := range ncSchedule {
+		if strings.HasPrefix(netDateString(nr.Date), prefix) {
+			ncRecords = append(ncRecords, nr)
+		}
+	}
+	conflicts := netControlConflicts(ncRecords)
+	if hospitalDir == "" {
+		return conflicts, nil
+	}
+	list, err := filepath.Glob(filepath.Join(hospitalDir, prefix+"*"))
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range list {
+		base := filepath.Base(f)
+		if len(base) < 10 {
+			continue
+		}
+		netDate, err := time.ParseInLocation("2006-01-02", base[:10], time.Local)
+		if err != nil {
+			continue
+		}
+		assignments, err := readHospitalLog(f, callsignDB)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read hospital log: %w", err)
+		}
+		conflicts = append(conflicts, hospitalConflicts(netDate, assignments)...)
+	}
+	return conflicts, nil
+}
+
+// checkConflicts prints the conflicts and refuses to proceed unless they are
+// acknowledged.
+func checkConflicts(conflicts []string, ack bool) error {
+	for _, c := range conflicts {
+		fmt.Printf("Schedule conflict: %v\n", c)
+	}
+	if len(conflicts) == 0 || ack {
+		return nil
+	}
+	return fmt.Errorf("Schedule has %v conflicts. Fix them or use -ack-conflicts to proceed.", len(conflicts))
+}
+
+// validateSchedule refuses to proceed if the schedule has unacknowledged
+// conflicts. See scheduleConflicts for the arguments.
+func validateSchedule(prefix string, ncSchedule []NetcontrolScheduleRecord, hospitalDir string, callsignDB map[string]Member, ack bool) error {
+	conflicts, err := scheduleConflicts(prefix, ncSchedule, hospitalDir, callsignDB)
+	if err != nil {
+		return err
+	}
+	return checkConflicts(conflicts, ack)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNetControlConflicts(t *testing.T) {
+	schedule := []NetcontrolScheduleRecord{
+		{Date: time.Date(2022, 10, 4, 0, 0, 0, 0, time.Local), Callsign: "K4LXF4"},
+		{Date: time.Date(2022, 10, 11, 0, 0, 0, 0, time.Local), Callsign: "N6DVS"},
+		{Date: time.Date(2022, 10, 4, 0, 0, 0, 0, time.Local), Callsign: "kj6abc"},
+	}
+	assert.Equal(t, []string{"Net 2022-10-04 is double booked: K4LXF4, KJ6ABC"}, netControlConflicts(schedule))
+	assert.Nil(t, netControlConflicts(schedule[:2]))
+}
+
+func TestHospitalConflicts(t *testing.T) {
+	callsigns := testSignupCallsigns()
+	dir := t.TempDir()
+	logFileName := filepath.Join(dir, "2022-10-26.txt")
+	err := ioutil.WriteFile(logFileName, []byte("GSH K4LXF4\nOCH K4LXF4\nGSH N6DVS\n"), 0644)
+	assert.Nil(t, err)
+
+	conflicts, err := scheduleConflicts("2022-10", nil, dir, callsigns)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"Hospital GSH on 2022-10-26 is double booked: K4LXF4, N6DVS",
+		"Operator K4LXF4 holds more than one hospital on 2022-10-26: GSH, OCH",
+	}, conflicts)
+
+	schedule, err := readHospitalAssignments(logFileName, callsigns)
+	assert.Nil(t, err)
+	assert.Equal(t, "N6DVS", schedule["GSH"].Callsign)
+}
+
+func TestScheduleConflictsWholeSchedule(t *testing.T) {
+	dir := t.TempDir()
+	err := ioutil.WriteFile(filepath.Join(dir, "2022-10-26.txt"), []byte("GSH K4LXF4\nGSH N6DVS\n"), 0644)
+	assert.Nil(t, err)
+
+	conflicts, err := scheduleConflicts("", nil, dir, testSignupCallsigns())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"Hospital GSH on 2022-10-26 is double booked: K4LXF4, N6DVS"}, conflicts)
+	assert.NotNil(t, validateSchedule("", nil, dir, testSignupCallsigns(), false))
+}
+
+func TestCheckConflicts(t *testing.T) {
+	assert.Nil(t, checkConflicts(nil, false))
+	assert.NotNil(t, checkConflicts([]string{"conflict"}, false))
+	assert.Nil(t, checkConflicts([]string{"conflict"}, true))
+}
+
+func TestIngestSignupsRefusesConflicts(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	configDir := filepath.Join(home, ".net-manager")
+	assert.Nil(t, os.MkdirAll(configDir, 0755))
+	schedule := "10/4/2022\tK4LXF4\n10/18/2022\tN6DVS\n10/18/2022\tKJ6ABC\n"
+	err := ioutil.WriteFile(filepath.Join(configDir, NetcontrolScheduleFileName), []byte(schedule), 0644)
+	assert.Nil(t, err)
+	mbox := filepath.Join(t.TempDir(), "signups.mbox")
+	err = ioutil.WriteFile(mbox, []byte(`From herman@munster.com Mon Sep 26 10:00:00 2022
+From: Herman <herman@munster.com>
+Date: Mon, 26 Sep 2022 10:00:00 -0700
+
+K4LXF4 10/11 and 10/4
+`), 0644)
+	assert.Nil(t, err)
+	err = ioutil.WriteFile(filepath.Join(configDir, CityResponsiblityScheduleFileName), []byte("10/4/2022\tSan Jose\n10/11/2022\tCampbell\n10/18/2022\tSan Jose\n"), 0644)
+	assert.Nil(t, err)
+	config := &Config{HospitalDir: t.TempDir()}
+	now := time.Date(2022, 9, 27, 0, 0, 0, 0, time.Local)
+
+	err = ingestSignups(mbox, true, false, config, testSignupCallsigns(), now)
+	assert.NotNil(t, err)
+	data, err := ioutil.ReadFile(filepath.Join(configDir, NetcontrolScheduleFileName))
+	assert.Nil(t, err)
+	assert.Equal(t, schedule, string(data))
+
+	err = ingestSignups(mbox, true, true, config, testSignupCallsigns(), now)
+	assert.Nil(t, err)
+	data, err = ioutil.ReadFile(filepath.Join(configDir, NetcontrolScheduleFileName))
+	assert.Nil(t, err)
+	assert.Equal(t, schedule+"10/11/2022\tK4LXF4\n", string(data))
+}
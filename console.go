@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+)
+
+// Console commands. An empty line starts a new section like in the net log.
+const (
+	UndoCommand    = "/undo"
+	SectionCommand = "/section"
+	QuitCommand    = "/quit"
+)
+
+// NetConsole logs a net interactively. Every entry is appended to the net log
+// right away and resolved against the call sign database.
+type NetConsole struct {
+	netLog    string
+	callSigns map[string]Member
+	// lines are the lines of the net log including lines that were there
+	// before the console started.
+	lines []string
+	out   io.Writer
+	now   func() time.Time
+}
+
+func newNetConsole(netLog string, callSigns map[string]Member, out io.Writer, now func() time.Time) (*NetConsole, error) {
+	c := &NetConsole{netLog: netLog, callSigns: callSigns, out: out, now: now}
+	data, err := ioutil.ReadFile(netLog)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("Failed to read net log: %w", err)
+	}
+	if len(data) > 0 {
+		c.lines = strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	}
+	return c, nil
+}
+
+// ConsoleCounter keeps running counts of the console and the last checkin.
+type ConsoleCounter struct {
+	callSigns    map[string]Member
+	sectionCount int
+	// previousSectionCount is the count of the section that was closed
+	// last.
+	previousSectionCount int
+	totalCount           int
+	// last describes the last checkin.
+	last string
+}
+
+func (c *ConsoleCounter) visitDup(d *DupCheckin) {
+	c.last = fmt.Sprintf("%v %v - duplicate", d.s, c.callSigns[d.s].Name)
+}
+
+func (c *ConsoleCounter) visitMember(m *MemberCheckin) {
+	c.sectionCount++
+	c.totalCount++
+	c.last = fmt.Sprintf("%v %v", m.s, c.callSigns[m.s].Name)
+}
+
+func (c *ConsoleCounter) visitSection() {
+	c.previousSectionCount = c.sectionCount
+	c.sectionCount = 0
+}
+
+func (c *ConsoleCounter) visitUnknown(u *UnknownCheckin) {
+	c.last = fmt.Sprintf("%v - unknown call sign", u.s)
+	if len(u.suggestions) > 0 {
+		c.last += fmt.Sprintf(", did you mean %v?", strings.Join(u.suggestions, " or "))
+	} else if validCallsign(u.s) {
+		c.last = fmt.Sprintf("%v - guest", u.s)
+	}
+}
+
+// count annotates the whole net log, so that undo and dups are always
+// consistent with -count.
+func (c *NetConsole) count() *ConsoleCounter {
+	records := make([]CheckinRecord, 0, len(c.lines))
+	for _, l := range c.lines {
+		records = append(records, parseCheckinLine(l))
+	}
+	cc := &ConsoleCounter{callSigns: c.callSigns}
+	for item := range annotateCheckins(c.callSigns, recordChan(records)) {
+		item.accept(cc)
+	}
+	// annotateCheckins closes the last section, so the current section is
+	// the one closed last.
+	cc.sectionCount = cc.previousSectionCount
+	if len(records) == 0 || records[len(records)-1].Callsign == "" {
+		// The last line is not a checkin.
+		cc.last = ""
+	}
+	return cc
+}
+
+func (c *NetConsole) printStatus() {
+	cc := c.count()
+	if cc.last != "" {
+		fmt.Fprintf(c.out, "%v\n", cc.last)
+	}
+	fmt.Fprintf(c.out, "Section: %v\tTotal: %v\n", cc.sectionCount, cc.totalCount)
+}
+
+// add appends the entry to the net log. Checkins without time are stamped
+// with the current time.
+func (c *NetConsole) add(entry string) error {
+	record := parseCheckinLine(entry)
+	if record.Callsign != "" && !record.Timed {
+		entry = c.now().Format("15:04") + " " + strings.TrimSpace(entry)
+	}
+	err := appendLine(c.netLog, entry)
+	if err != nil {
+		return fmt.Errorf("Failed to append to net log: %w", err)
+	}
+	c.lines = append(c.lines, entry)
+	return nil
+}
+
+// undo removes the last line of the net log.
+func (c *NetConsole) undo() error {
+	if len(c.lines) == 0 {
+		fmt.Fprintf(c.out, "Nothing to undo\n")
+		return nil
+	}
+	removed := c.lines[len(c.lines)-1]
+	c.lines = c.lines[:len(c.lines)-1]
+	data := ""
+	if len(c.lines) > 0 {
+		data = strings.Join(c.lines, "\n") + "\n"
+	}
+	err := ioutil.WriteFile(c.netLog, []byte(data), 0644)
+	if err != nil {
+		return fmt.Errorf("Failed to write net log: %w", err)
+	}
+	if removed == "" {
+		fmt.Fprintf(c.out, "Removed section break\n")
+	} else {
+		fmt.Fprintf(c.out, "Removed %v\n", removed)
+	}
+	return nil
+}
+
+// run reads entries and commands until quit or the end of input.
+func (c *NetConsole) run(in io.Reader) error {
+	fmt.Fprintf(c.out, "Logging to %v. Empty line or %v starts a new section, %v removes the last entry, %v exits.\n", c.netLog, SectionCommand, UndoCommand, QuitCommand)
+	c.printStatus()
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprintf(c.out, "> ")
+		if !scanner.Scan() {
+			fmt.Fprintf(c.out, "\n")
+			return scanner.Err()
+		}
+		entry := strings.TrimSpace(scanner.Text())
+		var err error
+		switch strings.ToLower(entry) {
+		case QuitCommand:
+			return nil
+		case UndoCommand:
+			err = c.undo()
+		case SectionCommand:
+			err = c.add("")
+		default:
+			err = c.add(entry)
+		}
+		if err != nil {
+			return err
+		}
+		c.printStatus()
+	}
+}
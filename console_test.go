@@ -0,0 +1,32 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNetConsole(t *testing.T) {
+	netLog := filepath.Join(t.TempDir(), "2022-10-04.txt")
+	assert.Nil(t, ioutil.WriteFile(netLog, []byte("19:30\n"), 0644))
+	var out strings.Builder
+	now := func() time.Time { return time.Date(2022, 10, 4, 19, 31, 0, 0, time.Local) }
+	c, err := newNetConsole(netLog, testSignupCallsigns(), &out, now)
+	assert.Nil(t, err)
+
+	err = c.run(strings.NewReader("k4lxf4 Campbell\nN6DVS\nXX1XX\n/undo\n\n19:40 K4LXF4; traffic\n/quit\nKJ6ABC\n"))
+	assert.Nil(t, err)
+	data, err := ioutil.ReadFile(netLog)
+	assert.Nil(t, err)
+	assert.Equal(t, "19:30\n19:31 k4lxf4 Campbell\n19:31 N6DVS\n\n19:40 K4LXF4; traffic\n", string(data))
+	s := out.String()
+	assert.Contains(t, s, "K4LXF4 Herman\nSection: 1\tTotal: 1\n")
+	assert.Contains(t, s, "XX1XX - guest\nSection: 2\tTotal: 2\n")
+	assert.Contains(t, s, "Removed 19:31 XX1XX\n")
+	assert.Contains(t, s, "> Section: 0\tTotal: 2\n")
+	assert.Contains(t, s, "K4LXF4 Herman - duplicate\nSection: 0\tTotal: 2\n")
+}
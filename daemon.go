@@ -0,0 +1,236 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const daemonPollInterval = time.Minute
+
+var defaultCheckTimes = []string{"08:00"}
+
+type DaemonConfig struct {
+	// CheckTimes are times of day in the format HH:MM when the daemon
+	// checks if emails should be sent.
+	CheckTimes []string `yaml:"check-times"`
+}
+
+// Daemon evaluates the same rules as -send-emails at configured times of day.
+// Configuration and call sign database are reloaded when they change.
+// Schedule files and the send ledger are read at every check.
+type Daemon struct {
+	config     *Config
+	callsignDB map[string]Member
+	mailer     Mailer
+	ledger     *Ledger
+	// newMailer creates a mailer after the configuration is reloaded.
+	newMailer  func(config *Config) (Mailer, error)
+	checkTimes []time.Duration
+	modTimes   map[string]time.Time
+}
+
+func newDaemon(config *Config, callsignDB map[string]Member, ledger *Ledger, newMailer func(config *Config) (Mailer, error)) (*Daemon, error) {
+	d := &Daemon{
+		callsignDB: callsignDB,
+		ledger:     ledger,
+		newMailer:  newMailer,
+		modTimes:   make(map[string]time.Time),
+	}
+	err := d.setConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	for _, fileName := range d.watchedFiles() {
+		d.modTimes[fileName], _ = modTime(fileName)
+	}
+	return d, nil
+}
+
+func (d *Daemon) setConfig(config *Config) error {
+	checkTimes := defaultCheckTimes
+	if config != nil && len(config.Daemon.CheckTimes) > 0 {
+		checkTimes = config.Daemon.CheckTimes
+	}
+	parsedTimes, err := parseCheckTimes(checkTimes)
+	if err != nil {
+		return err
+	}
+	mailer, err := d.newMailer(config)
+	if err != nil {
+		return fmt.Errorf("Failed to set up mailer: %w", err)
+	}
+	d.config = config
+	d.checkTimes = parsedTimes
+	d.mailer = mailer
+	return nil
+}
+
+func parseCheckTimes(checkTimes []string) ([]time.Duration, error) {
+	r := make([]time.Duration, 0, len(checkTimes))
+	for _, s := range checkTimes {
+		t, err := parseTimeOfDay(s)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse check time %v: %w", s, err)
+		}
+		r = append(r, t)
+	}
+	return r, nil
+}
+
+// parseTimeOfDay parses time in the format HH:MM as offset from midnight.
+func parseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// nextCheckTime returns the earliest check time strictly after now.
+func nextCheckTime(now time.Time, checkTimes []time.Duration) time.Time {
+	var next time.Time
+	for day := 0; day <= 1; day++ {
+		for _, offset := range checkTimes {
+			// Check times are wall clock times, also on days when daylight
+			// saving time starts or ends.
+			hour := int(offset / time.Hour)
+			minute := int(offset % time.Hour / time.Minute)
+			t := time.Date(now.Year(), now.Month(), now.Day()+day, hour, minute, 0, 0, now.Location())
+			if t.After(now) && (next.IsZero() || t.Before(next)) {
+				next = t
+			}
+		}
+		if !next.IsZero() {
+			return next
+		}
+	}
+	return next
+}
+
+func (d *Daemon) watchedFiles() []string {
+	return []string{
+		configPath("net-manager.conf", ".net-manager.conf"),
+		configPath(callsignDB, callsignDB),
+	}
+}
+
+func modTime(fileName string) (time.Time, error) {
+	info, err := os.Stat(fileName)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
+
+// reloadChanged rereads the files that changed since the last check. Broken
+// files are reported and the previous state is kept. It reports whether the
+// configuration was reloaded.
+func (d *Daemon) reloadChanged() (configReloaded bool) {
+	for _, fileName := range d.watchedFiles() {
+		t, err := modTime(fileName)
+		if err != nil || t.Equal(d.modTimes[fileName]) {
+			continue
+		}
+		d.modTimes[fileName] = t
+		log.Infof("Reloading changed file: %v", fileName)
+		switch filepath.Base(fileName) {
+		case "net-manager.conf", ".net-manager.conf":
+			config := readConfig()
+			if config == nil {
+				log.Errorf("Keeping previous configuration")
+				continue
+			}
+			err = d.setConfig(config)
+			configReloaded = err == nil
+			if configReloaded {
+				// Tactical call signs are in the configuration.
+				var callsigns map[string]Member
+				callsigns, err = loadCallsignDB(config)
+				if err == nil {
+					d.callsignDB = callsigns
+				}
+			}
+		case callsignDB:
+			var callsigns map[string]Member
+			callsigns, err = loadCallsignDB(d.config)
+			if err == nil {
+				d.callsignDB = callsigns
+			}
+		}
+		if err != nil {
+			log.Errorf("Failed to reload %v: %v", fileName, err)
+		}
+	}
+	return
+}
+
+// reloadLedger rereads the send ledger, so that emails sent by cron or a
+// manual -send-emails while the daemon is running are not sent again.
+func (d *Daemon) reloadLedger() error {
+	ledger, err := readLedger(d.ledger.fileName)
+	if err != nil {
+		return err
+	}
+	ledger.DryRun = d.ledger.DryRun
+	d.ledger = ledger
+	return nil
+}
+
+func (d *Daemon) dispatch(now time.Time) {
+	log.Infof("Checking if emails should be sent")
+	err := d.reloadLedger()
+	if err != nil {
+		log.Errorf("Not sending emails: %v", err)
+		return
+	}
+	err = dispatchEmails(now, d.callsignDB, d.config, d.mailer, d.ledger, false, false)
+	if err != nil {
+		log.Errorf("Failed to dispatch emails: %v", err)
+	}
+}
+
+// run blocks until SIGTERM or interrupt is received. A dispatch in progress
+// is completed before shutting down.
+func (d *Daemon) run() {
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGTERM, os.Interrupt)
+	defer signal.Stop(stop)
+
+	ticker := time.NewTicker(daemonPollInterval)
+	defer ticker.Stop()
+	next := nextCheckTime(time.Now(), d.checkTimes)
+	log.Infof("Next check at %v", next)
+	timer := time.NewTimer(time.Until(next))
+	defer timer.Stop()
+	for {
+		select {
+		case sig := <-stop:
+			log.Infof("Received %v. Shutting down.", sig)
+			return
+		case <-ticker.C:
+			if d.reloadChanged() {
+				next = nextCheckTime(time.Now(), d.checkTimes)
+				log.Infof("Next check at %v", next)
+				if !timer.Stop() {
+					select {
+					case <-timer.C:
+					default:
+					}
+				}
+				timer.Reset(time.Until(next))
+			}
+		case <-timer.C:
+			d.reloadChanged()
+			d.dispatch(time.Now())
+			next = nextCheckTime(time.Now(), d.checkTimes)
+			log.Infof("Next check at %v", next)
+			timer.Reset(time.Until(next))
+		}
+	}
+}
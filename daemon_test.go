@@ -0,0 +1,57 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCheckTimes(t *testing.T) {
+	checkTimes, err := parseCheckTimes([]string{"08:00", "18:30"})
+	assert.Nil(t, err)
+	assert.Equal(t, []time.Duration{8 * time.Hour, 18*time.Hour + 30*time.Minute}, checkTimes)
+
+	_, err = parseCheckTimes([]string{"8am"})
+	assert.NotNil(t, err)
+}
+
+func TestNextCheckTime(t *testing.T) {
+	checkTimes := []time.Duration{18 * time.Hour, 8 * time.Hour}
+	assert.Equal(t, time.Date(2022, 10, 2, 8, 0, 0, 0, time.Local),
+		nextCheckTime(time.Date(2022, 10, 2, 7, 0, 0, 0, time.Local), checkTimes))
+	assert.Equal(t, time.Date(2022, 10, 2, 18, 0, 0, 0, time.Local),
+		nextCheckTime(time.Date(2022, 10, 2, 8, 0, 0, 0, time.Local), checkTimes))
+	assert.Equal(t, time.Date(2022, 10, 3, 8, 0, 0, 0, time.Local),
+		nextCheckTime(time.Date(2022, 10, 2, 19, 0, 0, 0, time.Local), checkTimes))
+}
+
+func TestNextCheckTimeDaylightSaving(t *testing.T) {
+	location, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Skipf("No time zone database: %v", err)
+	}
+	checkTimes := []time.Duration{8 * time.Hour}
+	// Daylight saving time ended on 11/6/2022 and started on 3/13/2022.
+	assert.Equal(t, time.Date(2022, 11, 6, 8, 0, 0, 0, location),
+		nextCheckTime(time.Date(2022, 11, 6, 0, 30, 0, 0, location), checkTimes))
+	assert.Equal(t, time.Date(2022, 3, 13, 8, 0, 0, 0, location),
+		nextCheckTime(time.Date(2022, 3, 13, 0, 30, 0, 0, location), checkTimes))
+}
+
+func TestDaemonReloadLedger(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), SendLedgerFileName)
+	ledger, err := readLedger(fileName)
+	assert.Nil(t, err)
+	d := &Daemon{ledger: ledger}
+
+	// Another run sends the report while the daemon is running.
+	other, err := readLedger(fileName)
+	assert.Nil(t, err)
+	assert.Nil(t, other.Record(ReportKind, "2022-09", time.Now()))
+
+	assert.False(t, d.ledger.Sent(ReportKind, "2022-09"))
+	assert.Nil(t, d.reloadLedger())
+	assert.True(t, d.ledger.Sent(ReportKind, "2022-09"))
+}
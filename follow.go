@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+// followInterval is how often -follow checks the net log for new lines.
+const followInterval = time.Second
+
+// NetLogFollower counts checkins of a net log as lines are appended to it.
+type NetLogFollower struct {
+	netLog            string
+	callSigns         map[string]Member
+	acceptSuggestions bool
+	// counted are the complete lines of the net log that were counted.
+	counted   []byte
+	annotator *CheckinAnnotator
+	counter   *CheckinCounter
+}
+
+func newNetLogFollower(netLog string, callSigns map[string]Member, acceptSuggestions bool) *NetLogFollower {
+	f := &NetLogFollower{netLog: netLog, callSigns: callSigns, acceptSuggestions: acceptSuggestions}
+	f.reset()
+	return f
+}
+
+func (f *NetLogFollower) reset() {
+	f.counted = nil
+	f.annotator = newCheckinAnnotator(f.callSigns)
+	f.annotator.acceptSuggestions = f.acceptSuggestions
+	f.counter = &CheckinCounter{}
+}
+
+// poll counts lines appended since the last poll and reports whether there
+// were any. The whole net log is read every time, because editors rewrite
+// the file on save. If lines that were counted changed, e.g. a typo was
+// fixed, the net log is counted from the start.
+func (f *NetLogFollower) poll() (bool, error) {
+	data, err := ioutil.ReadFile(f.netLog)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	// The last line may still be being written.
+	data = data[:bytes.LastIndexByte(data, '\n')+1]
+	if bytes.Equal(data, f.counted) {
+		return false, nil
+	}
+	if !bytes.HasPrefix(data, f.counted) {
+		fmt.Printf("Net log was rewritten, counting from the start\n")
+		f.reset()
+	}
+	added := bytes.TrimSuffix(data[len(f.counted):], []byte("\n"))
+	if len(data) > len(f.counted) {
+		for _, line := range bytes.Split(added, []byte("\n")) {
+			if item := f.annotator.annotate(parseCheckinLine(string(line))); item != nil {
+				item.accept(f.counter)
+			}
+		}
+	}
+	f.counted = data
+	return true, nil
+}
+
+func (f *NetLogFollower) printCounts() {
+	fmt.Printf("Confirmed members: %v, section: %v, dups: %v, unknown: %v", f.counter.totalCount, f.counter.sectionCount, f.counter.dupCount, f.counter.unknownCount)
+	if f.counter.trafficCount > 0 {
+		fmt.Printf(", traffic: %v", f.counter.trafficCount)
+	}
+	fmt.Printf("\n")
+}
+
+// followCheckins counts checkins of the net log and keeps counting lines
+// appended to it until stop is closed.
+func followCheckins(netLog string, callSigns map[string]Member, acceptSuggestions bool, interval time.Duration, stop <-chan struct{}) error {
+	f := newNetLogFollower(netLog, callSigns, acceptSuggestions)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		changed, err := f.poll()
+		if err != nil {
+			return fmt.Errorf("Failed to read net log: %w", err)
+		}
+		if changed {
+			f.printCounts()
+		}
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNetLogFollower(t *testing.T) {
+	netLog := filepath.Join(t.TempDir(), "2022-10-04.txt")
+	f := newNetLogFollower(netLog, testSignupCallsigns(), false)
+	changed, err := f.poll()
+	assert.Nil(t, err)
+	assert.False(t, changed)
+
+	assert.Nil(t, ioutil.WriteFile(netLog, []byte("19:30\nK4LXF4\nN6DV"), 0644))
+	changed, err = f.poll()
+	assert.Nil(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, 1, f.counter.totalCount)
+
+	file, err := os.OpenFile(netLog, os.O_APPEND|os.O_WRONLY, 0644)
+	assert.Nil(t, err)
+	_, err = file.WriteString("S\n\nK4LXF4\nXX1XX\n")
+	assert.Nil(t, err)
+	file.Close()
+	changed, err = f.poll()
+	assert.Nil(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, 2, f.counter.totalCount)
+	assert.Equal(t, 0, f.counter.sectionCount)
+	assert.Equal(t, 1, f.counter.dupCount)
+	assert.Equal(t, 1, f.counter.unknownCount)
+
+	changed, err = f.poll()
+	assert.Nil(t, err)
+	assert.False(t, changed)
+
+	assert.Nil(t, ioutil.WriteFile(netLog, []byte("K4LXF4\n"), 0644))
+	changed, err = f.poll()
+	assert.Nil(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, 1, f.counter.totalCount)
+	assert.Equal(t, 0, f.counter.dupCount)
+}
+
+func TestNetLogFollowerEditInPlace(t *testing.T) {
+	netLog := filepath.Join(t.TempDir(), "2022-10-04.txt")
+	assert.Nil(t, ioutil.WriteFile(netLog, []byte("K4LXF4\nXX1XX\n"), 0644))
+	f := newNetLogFollower(netLog, testSignupCallsigns(), false)
+	changed, err := f.poll()
+	assert.Nil(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, 1, f.counter.totalCount)
+	assert.Equal(t, 1, f.counter.unknownCount)
+
+	// An editor fixes the typo and saves the file of the same size.
+	assert.Nil(t, ioutil.WriteFile(netLog, []byte("K4LXF4\nN6DVS\n"), 0644))
+	changed, err = f.poll()
+	assert.Nil(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, 2, f.counter.totalCount)
+	assert.Equal(t, 0, f.counter.unknownCount)
+
+	assert.Nil(t, ioutil.WriteFile(netLog, []byte(""), 0644))
+	changed, err = f.poll()
+	assert.Nil(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, 0, f.counter.totalCount)
+	assert.Equal(t, 0, f.counter.sectionCount)
+}
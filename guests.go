@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// netLogPattern matches names of net logs like 2022-09-13.txt.
+const netLogPattern = "[0-9][0-9][0-9][0-9]-[0-9][0-9]-[0-9][0-9]*"
+
+// guestCallsignPattern matches amateur radio call signs: a prefix with a
+// digit followed by a suffix of letters, e.g. W1XYZ or KJ6ABC.
+var guestCallsignPattern = regexp.MustCompile(`^[A-Z0-9]?[A-Z][0-9]?[0-9][A-Z]{1,4}$`)
+
+// validCallsign reports whether the call sign looks like a real call sign
+// rather than a typo or a note.
+func validCallsign(callsign string) bool {
+	return guestCallsignPattern.MatchString(normalizeCallsign(callsign))
+}
+
+// Guest is a visitor that checked into nets without being a member.
+type Guest struct {
+	Callsign string
+	// Nets are dates of nets the guest checked into, oldest first.
+	Nets []string
+	// Location is the last location the guest reported.
+	Location string
+}
+
+func (g Guest) repeat() bool {
+	return len(g.Nets) > 1
+}
+
+// GuestTally collects guests of one net log.
+type GuestTally struct {
+	guests map[string]CheckinRecord
+}
+
+func (c *GuestTally) visitDup(d *DupCheckin) {
+}
+
+func (c *GuestTally) visitMember(m *MemberCheckin) {
+}
+
+func (c *GuestTally) visitSection() {
+}
+
+func (c *GuestTally) visitUnknown(u *UnknownCheckin) {
+	if len(u.suggestions) > 0 || !validCallsign(u.s) {
+		// Unknown call signs close to member call signs are typos.
+		return
+	}
+	callsign := normalizeCallsign(u.s)
+	if _, ok := c.guests[callsign]; !ok || u.record.Location != "" {
+		c.guests[callsign] = u.record
+	}
+}
+
+// readGuests walks all net logs of the directory and returns guests sorted
+// by the number of nets they visited.
+func readGuests(logDirectory string, callSigns map[string]Member) ([]Guest, error) {
+	list, err := filepath.Glob(filepath.Join(logDirectory, netLogPattern))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(list)
+	guests := make(map[string]*Guest)
+	for _, f := range list {
+		if info, err := os.Stat(f); err != nil || info.IsDir() {
+			continue
+		}
+		records, err := readCheckinRecords(f)
+		if err != nil {
+			return nil, err
+		}
+		tally := &GuestTally{make(map[string]CheckinRecord)}
+		for c := range annotateCheckins(callSigns, recordChan(records)) {
+			c.accept(tally)
+		}
+		for callsign, record := range tally.guests {
+			g, ok := guests[callsign]
+			if !ok {
+				g = &Guest{Callsign: callsign}
+				guests[callsign] = g
+			}
+			g.Nets = append(g.Nets, logDate(f))
+			if record.Location != "" {
+				g.Location = record.Location
+			}
+		}
+	}
+	res := make([]Guest, 0, len(guests))
+	for _, g := range guests {
+		res = append(res, *g)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if len(res[i].Nets) != len(res[j].Nets) {
+			return len(res[i].Nets) > len(res[j].Nets)
+		}
+		return res[i].Callsign < res[j].Callsign
+	})
+	return res, nil
+}
+
+func formatGuests(guests []Guest) string {
+	var sb strings.Builder
+	repeat := 0
+	for _, g := range guests {
+		fmt.Fprintf(&sb, "%v\t%d\t%v\t%v\t%v\n", g.Callsign, len(g.Nets), g.Nets[0], g.Nets[len(g.Nets)-1], g.Location)
+		if g.repeat() {
+			repeat++
+		}
+	}
+	fmt.Fprintf(&sb, "Guests: %v, repeat visitors: %v\n", len(guests), repeat)
+	return sb.String()
+}
+
+// exportGuests writes guests as csv for the membership chair.
+func exportGuests(w io.Writer, guests []Guest) error {
+	cw := csv.NewWriter(w)
+	err := cw.Write([]string{"Call sign", "Visits", "First net", "Last net", "Location", "Nets"})
+	if err != nil {
+		return err
+	}
+	for _, g := range guests {
+		err = cw.Write([]string{g.Callsign, strconv.Itoa(len(g.Nets)), g.Nets[0], g.Nets[len(g.Nets)-1], g.Location, strings.Join(g.Nets, " ")})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
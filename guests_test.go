@@ -0,0 +1,38 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidCallsign(t *testing.T) {
+	assert.True(t, validCallsign("W1XYZ"))
+	assert.True(t, validCallsign("VE3ABC/P"))
+	assert.True(t, validCallsign("2E0ABC"))
+	assert.False(t, validCallsign("KJ6"))
+	assert.False(t, validCallsign("NET"))
+}
+
+func TestReadGuests(t *testing.T) {
+	dir := t.TempDir()
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "2022-10-04.txt"), []byte("N6DVS\nW1XYZ Boston\nW1XYZ\nHELLO\n"), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "2022-10-11.txt"), []byte("w1xyz/m\nVE3ABC\nK4LXF4\nKJ6ABS\n"), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "notes.txt"), []byte("W9ABC\n"), 0644))
+	assert.Nil(t, os.Mkdir(filepath.Join(dir, "2022-11-01"), 0755))
+	guests, err := readGuests(dir, testSignupCallsigns())
+	assert.Nil(t, err)
+	assert.Equal(t, []Guest{
+		{"W1XYZ", []string{"2022-10-04", "2022-10-11"}, "Boston"},
+		{"VE3ABC", []string{"2022-10-11"}, ""},
+	}, guests)
+	assert.Equal(t, "W1XYZ\t2\t2022-10-04\t2022-10-11\tBoston\nVE3ABC\t1\t2022-10-11\t2022-10-11\t\nGuests: 2, repeat visitors: 1\n", formatGuests(guests))
+
+	var buf bytes.Buffer
+	assert.Nil(t, exportGuests(&buf, guests))
+	assert.Equal(t, "Call sign,Visits,First net,Last net,Location,Nets\nW1XYZ,2,2022-10-04,2022-10-11,Boston,2022-10-04 2022-10-11\nVE3ABC,1,2022-10-11,2022-10-11,,2022-10-11\n", buf.String())
+}
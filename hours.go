@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// HoursPolicy is the accounting of volunteer hours of one type of net.
+type HoursPolicy struct {
+	// PerCheckin is hours per checkin. It's only used for logs without
+	// timestamps.
+	PerCheckin float64 `yaml:"per-checkin"`
+	// Prep is preparation time of every operator.
+	Prep float64 `yaml:"prep"`
+	// Reporting is wrap-up time of the net that is added once per net log.
+	Reporting float64 `yaml:"reporting"`
+	// Minimum is the least number of hours of an operator including
+	// preparation.
+	Minimum float64 `yaml:"minimum"`
+	// RoundQuarter rounds hours of every operator to quarter hours.
+	RoundQuarter bool `yaml:"round-to-quarter-hour"`
+}
+
+// HoursConfig holds hours policies of net types. Missing policies use the
+// defaults.
+type HoursConfig struct {
+	Net      *HoursPolicy `yaml:"net"`
+	Hospital *HoursPolicy `yaml:"hospital"`
+}
+
+var (
+	// DefaultNetHours is 20 minutes per checkin, half an hour of
+	// preparation and a quarter of an hour of reporting.
+	DefaultNetHours = HoursPolicy{PerCheckin: 1.0 / 3, Prep: 0.5, Reporting: 0.25}
+	// DefaultHospitalHours is half an hour per hospital station and a quarter
+	// of an hour of reporting.
+	DefaultHospitalHours = HoursPolicy{PerCheckin: 0.5, Reporting: 0.25}
+)
+
+func defaultHoursConfig() HoursConfig {
+	net := DefaultNetHours
+	hospital := DefaultHospitalHours
+	return HoursConfig{Net: &net, Hospital: &hospital}
+}
+
+func (c HoursConfig) net() HoursPolicy {
+	if c.Net == nil {
+		return DefaultNetHours
+	}
+	return *c.Net
+}
+
+func (c HoursConfig) hospital() HoursPolicy {
+	if c.Hospital == nil {
+		return DefaultHospitalHours
+	}
+	return *c.Hospital
+}
+
+// operatorHours returns hours of an operator who spent the time on the net.
+func (p HoursPolicy) operatorHours(hours float64) float64 {
+	hours += p.Prep
+	if hours < p.Minimum {
+		hours = p.Minimum
+	}
+	if p.RoundQuarter {
+		hours = math.Round(hours*4) / 4
+	}
+	return hours
+}
+
+// legend describes the policy for the report.
+func (p HoursPolicy) legend(name string) string {
+	parts := []string{
+		fmt.Sprintf("actual time from timestamps or %0.3f per checkin", p.PerCheckin),
+		fmt.Sprintf("%0.3f preparation", p.Prep),
+		fmt.Sprintf("%0.3f reporting", p.Reporting),
+	}
+	if p.Minimum > 0 {
+		parts = append(parts, fmt.Sprintf("minimum %0.3f", p.Minimum))
+	}
+	if p.RoundQuarter {
+		parts = append(parts, "rounded to quarter hours")
+	}
+	return fmt.Sprintf("%v: %v", name, strings.Join(parts, ", "))
+}
+
+// hoursLegend describes hours policies of all net types.
+func hoursLegend(c HoursConfig) []string {
+	return []string{c.net().legend("Net"), c.hospital().legend("Hospital Net")}
+}
+
+// netLogTime returns the time of the net without preparation and reporting
+// with the number of member checkins.
+func netLogTime(records []CheckinRecord, checkins int, policy HoursPolicy) (float64, string) {
+	if start, end, ok := netSpan(records); ok {
+		return (end - start).Hours(), TimestampsMethod
+	}
+	return float64(checkins) * policy.PerCheckin, EstimateMethod
+}
+
+// netLogHours returns hours of the net control of the net log with the
+// number of member checkins.
+func netLogHours(records []CheckinRecord, checkins int, policy HoursPolicy) (float64, string) {
+	netHours, method := netLogTime(records, checkins, policy)
+	return policy.operatorHours(netHours) + policy.Reporting, method
+}
+
+// hospitalLogHours returns hours of the hospital net log and hours of every
+// operator by normalized call sign. If the log has timestamps every operator is counted
+// from their checkin till the end of the net.
+func hospitalLogHours(records []CheckinRecord, policy HoursPolicy) (float64, map[string]float64, string) {
+	start, end, timed := netSpan(records)
+	totalHours := policy.Reporting
+	operators := make(map[string]float64)
+	for _, r := range records {
+		// Hospital net log lines are acronyms followed by call signs.
+		if r.Callsign == "" {
+			continue
+		}
+		hours := policy.operatorHours(policy.PerCheckin)
+		if timed {
+			checkin := start
+			if r.Timed {
+				checkin = r.Time
+				if checkin < start {
+					checkin += 24 * time.Hour
+				}
+			}
+			hours = policy.operatorHours((end - checkin).Hours())
+		}
+		operators[normalizeCallsign(r.Location)] += hours
+		totalHours += hours
+	}
+	if timed {
+		return totalHours, operators, TimestampsMethod
+	}
+	return totalHours, operators, EstimateMethod
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOperatorHours(t *testing.T) {
+	p := HoursPolicy{Prep: 0.5, Minimum: 1, RoundQuarter: true}
+	assert.Equal(t, 1.0, p.operatorHours(0.2))
+	assert.Equal(t, 1.5, p.operatorHours(0.9))
+	assert.Equal(t, 1.75, p.operatorHours(1.2))
+}
+
+func TestParseHoursConfig(t *testing.T) {
+	config, err := parseConfig([]byte("hours:\n  net:\n    per-checkin: 0.25\n    minimum: 1\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, HoursPolicy{PerCheckin: 0.25, Prep: 0.5, Reporting: 0.25, Minimum: 1}, config.Hours.net())
+	assert.Equal(t, DefaultHospitalHours, config.Hours.hospital())
+	assert.Equal(t, DefaultNetHours, HoursConfig{}.net())
+	assert.Equal(t, "Net: actual time from timestamps or 0.250 per checkin, 0.500 preparation, 0.250 reporting, minimum 1.000", config.Hours.net().legend("Net"))
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/gomail.v2"
+)
+
+const (
+	icalDateFormat = "20060102T150405Z"
+	icalDayFormat  = "20060102"
+	// icalLineLength is the maximum length of a content line in octets.
+	icalLineLength = 75
+)
+
+// NetTime is the time of day and duration of a net.
+type NetTime struct {
+	// Start is the time of day in the format HH:MM.
+	Start string `yaml:"start"`
+	// Duration is the duration of the net in minutes. It's an hour by
+	// default.
+	Duration int `yaml:"duration"`
+}
+
+func (n NetTime) configured() bool {
+	return n.Start != ""
+}
+
+// span returns the start and the end of the net on the date.
+func (n NetTime) span(date time.Time) (start time.Time, end time.Time, err error) {
+	offset, err := parseTimeOfDay(n.Start)
+	if err != nil {
+		return start, end, err
+	}
+	start = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location()).Add(offset)
+	duration := time.Hour
+	if n.Duration > 0 {
+		duration = time.Duration(n.Duration) * time.Minute
+	}
+	return start, start.Add(duration), nil
+}
+
+// CalendarEvent is a VEVENT of an iCalendar object. UID must be stable, so
+// that calendar clients update an event instead of duplicating it.
+type CalendarEvent struct {
+	UID   string
+	Start time.Time
+	End   time.Time
+	// AllDay events only use dates of Start and End.
+	AllDay      bool
+	Summary     string
+	Description string
+	Location    string
+	Organizer   string
+	Attendees   []Member
+}
+
+// writeCalendar writes events as an iCalendar object according to RFC 5545.
+func writeCalendar(w io.Writer, method string, events []CalendarEvent, stamp time.Time) error {
+	var lines []string
+	lines = append(lines,
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//net_manager//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:"+method,
+	)
+	for _, e := range events {
+		lines = append(lines,
+			"BEGIN:VEVENT",
+			"UID:"+e.UID,
+			"DTSTAMP:"+stamp.UTC().Format(icalDateFormat),
+		)
+		if e.AllDay {
+			lines = append(lines,
+				"DTSTART;VALUE=DATE:"+e.Start.Format(icalDayFormat),
+				"DTEND;VALUE=DATE:"+e.End.Format(icalDayFormat),
+			)
+		} else {
+			lines = append(lines,
+				"DTSTART:"+e.Start.UTC().Format(icalDateFormat),
+				"DTEND:"+e.End.UTC().Format(icalDateFormat),
+			)
+		}
+		lines = append(lines, "SUMMARY:"+escapeICalText(e.Summary))
+		if e.Description != "" {
+			lines = append(lines, "DESCRIPTION:"+escapeICalText(e.Description))
+		}
+		if e.Location != "" {
+			lines = append(lines, "LOCATION:"+escapeICalText(e.Location))
+		}
+		if e.Organizer != "" {
+			lines = append(lines, "ORGANIZER:mailto:"+e.Organizer)
+		}
+		for _, a := range e.Attendees {
+			lines = append(lines, fmt.Sprintf("ATTENDEE;CN=%v;ROLE=REQ-PARTICIPANT;PARTSTAT=NEEDS-ACTION;RSVP=TRUE:mailto:%v", quoteICalParam(a.Name), a.Email))
+		}
+		lines = append(lines, "END:VEVENT")
+	}
+	lines = append(lines, "END:VCALENDAR")
+	for _, l := range lines {
+		_, err := io.WriteString(w, foldICalLine(l)+"\r\n")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func escapeICalText(s string) string {
+	r := strings.NewReplacer("\\", "\\\\", ";", "\\;", ",", "\\,", "\r\n", "\\n", "\n", "\\n")
+	return r.Replace(s)
+}
+
+func quoteICalParam(s string) string {
+	return "\"" + strings.ReplaceAll(s, "\"", "'") + "\""
+}
+
+// foldICalLine splits lines longer than 75 octets without breaking utf-8
+// characters.
+func foldICalLine(l string) string {
+	var sb strings.Builder
+	lineLength := 0
+	for _, r := range l {
+		n := len(string(r))
+		if lineLength+n > icalLineLength {
+			sb.WriteString("\r\n ")
+			lineLength = 1
+		}
+		sb.WriteRune(r)
+		lineLength += n
+	}
+	return sb.String()
+}
+
+// eventUID returns a stable identifier of the event of the kind on the date.
+func eventUID(kind string, date time.Time, config *Config) string {
+	domain := "net-manager"
+	if config != nil && config.Station.Call != "" {
+		domain = strings.ToLower(config.Station.Call) + ".net-manager"
+	}
+	return fmt.Sprintf("%v-%v@%v", kind, date.Format("20060102"), domain)
+}
+
+// attachInvite attaches the event as invite.ics calendar request.
+func attachInvite(m *gomail.Message, event CalendarEvent, stamp time.Time) error {
+	var buf bytes.Buffer
+	err := writeCalendar(&buf, "REQUEST", []CalendarEvent{event}, stamp)
+	if err != nil {
+		return err
+	}
+	m.Attach("invite.ics",
+		gomail.SetCopyFunc(func(w io.Writer) error {
+			_, err := w.Write(buf.Bytes())
+			return err
+		}),
+		gomail.SetHeader(map[string][]string{
+			"Content-Type": {"text/calendar; method=REQUEST; charset=UTF-8"},
+		}),
+	)
+	return nil
+}
+
+// netEvent returns a calendar event of the net on the date. If net time
+// isn't configured the event lasts the whole day.
+func netEvent(netTime NetTime, date time.Time) (CalendarEvent, error) {
+	if !netTime.configured() {
+		return CalendarEvent{Start: date, End: date.AddDate(0, 0, 1), AllDay: true}, nil
+	}
+	start, end, err := netTime.span(date)
+	if err != nil {
+		return CalendarEvent{}, err
+	}
+	return CalendarEvent{Start: start, End: end}, nil
+}
+
+// exportCalendar writes the net control schedule of every month of the city
+// responsibility schedule and hospital nets of these months as iCalendar
+// feed.
+func exportCalendar(w io.Writer, now time.Time, config *Config, callsignDB map[string]Member) error {
+	if config == nil {
+		return fmt.Errorf("Calendar export requires a config file")
+	}
+	citySchedule, err := readCityResponsibilitySchedule()
+	if err != nil {
+		return fmt.Errorf("Failed to read city responsibility schedule: %w", err)
+	}
+	ncSchedule, err := readNetcontrolSchedule()
+	if err != nil {
+		return fmt.Errorf("Failed to parse net control schedule: %w", err)
+	}
+	// The city schedule may be out of order, so every month is exported once.
+	seenMonths := make(map[string]struct{})
+	months := make([]time.Time, 0)
+	for _, cr := range citySchedule {
+		monthStart := time.Date(cr.Date.Year(), cr.Date.Month(), 1, 0, 0, 0, 0, cr.Date.Location())
+		if _, ok := seenMonths[monthPrefixString(monthStart)]; ok {
+			continue
+		}
+		seenMonths[monthPrefixString(monthStart)] = struct{}{}
+		months = append(months, monthStart)
+	}
+	sort.Slice(months, func(i, j int) bool {
+		return months[i].Before(months[j])
+	})
+	events := make([]CalendarEvent, 0)
+	for _, monthStart := range months {
+		_, schedule := monthSchedule(monthStart, ncSchedule, citySchedule)
+		for _, sr := range schedule {
+			// Dates of the city schedule are in UTC, but the net time is
+			// local like in invites.
+			netDate := time.Date(sr.Date.Year(), sr.Date.Month(), sr.Date.Day(), 0, 0, 0, 0, time.Local)
+			e, err := netEvent(config.NetTime, netDate)
+			if err != nil {
+				return fmt.Errorf("Failed to parse net time: %w", err)
+			}
+			e.UID = eventUID("net", netDate, config)
+			e.Location = sr.City
+			if sr.Callsign != "" {
+				e.Summary = fmt.Sprintf("Net: %v, net control %v", sr.City, sr.Callsign)
+			} else {
+				e.Summary = fmt.Sprintf("Net: %v, net control open", sr.City)
+			}
+			events = append(events, e)
+		}
+		if config.HospitalDir == "" {
+			continue
+		}
+		monthPrefix := monthPrefixString(monthStart)
+		netDate, err := hospitalNetDate(monthPrefix, config.HospitalDir)
+		if err != nil {
+			return err
+		}
+		e, err := netEvent(config.HospitalNetTime, netDate)
+		if err != nil {
+			return fmt.Errorf("Failed to parse hospital net time: %w", err)
+		}
+		e.UID = eventUID("hospital-net", netDate, config)
+		e.Summary = "Hospital Net"
+		hospitalSchedule, err := readHospitalSchedule(monthPrefix, config.HospitalDir, callsignDB)
+		if err != nil {
+			// Standard output may be the calendar feed.
+			log.Errorf("Failed to read hospital schedule for %v: %v", monthPrefix, err)
+		}
+		var description []string
+		for _, h := range Hospitals {
+			if member, ok := hospitalSchedule[h.Acronym]; ok {
+				description = append(description, fmt.Sprintf("%v: %v", h.FullName, member.Callsign))
+			} else {
+				description = append(description, fmt.Sprintf("%v: available", h.FullName))
+			}
+		}
+		e.Description = strings.Join(description, "\n")
+		events = append(events, e)
+	}
+	return writeCalendar(w, "PUBLISH", events, now)
+}
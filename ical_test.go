@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNetTimeSpan(t *testing.T) {
+	date := time.Date(2022, 10, 4, 0, 0, 0, 0, time.Local)
+	start, end, err := NetTime{Start: "19:30"}.span(date)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2022, 10, 4, 19, 30, 0, 0, time.Local), start)
+	assert.Equal(t, time.Date(2022, 10, 4, 20, 30, 0, 0, time.Local), end)
+
+	_, end, err = NetTime{Start: "19:00", Duration: 45}.span(date)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2022, 10, 4, 19, 45, 0, 0, time.Local), end)
+
+	_, _, err = NetTime{Start: "7pm"}.span(date)
+	assert.NotNil(t, err)
+}
+
+func TestWriteCalendar(t *testing.T) {
+	var buf bytes.Buffer
+	err := writeCalendar(&buf, "REQUEST", []CalendarEvent{{
+		UID:       "net-20221004@n6dvs.net-manager",
+		Start:     time.Date(2022, 10, 5, 2, 30, 0, 0, time.UTC),
+		End:       time.Date(2022, 10, 5, 3, 30, 0, 0, time.UTC),
+		Summary:   "Net control: K4LXF4, San Jose; Tuesday",
+		Organizer: "n6dvs@example.com",
+	}}, time.Date(2022, 10, 2, 8, 0, 0, 0, time.UTC))
+	assert.Nil(t, err)
+	assert.Equal(t, "BEGIN:VCALENDAR\r\n"+
+		"VERSION:2.0\r\n"+
+		"PRODID:-//net_manager//EN\r\n"+
+		"CALSCALE:GREGORIAN\r\n"+
+		"METHOD:REQUEST\r\n"+
+		"BEGIN:VEVENT\r\n"+
+		"UID:net-20221004@n6dvs.net-manager\r\n"+
+		"DTSTAMP:20221002T080000Z\r\n"+
+		"DTSTART:20221005T023000Z\r\n"+
+		"DTEND:20221005T033000Z\r\n"+
+		"SUMMARY:Net control: K4LXF4\\, San Jose\\; Tuesday\r\n"+
+		"ORGANIZER:mailto:n6dvs@example.com\r\n"+
+		"END:VEVENT\r\n"+
+		"END:VCALENDAR\r\n", buf.String())
+}
+
+func TestFoldICalLine(t *testing.T) {
+	line := "DESCRIPTION:" + strings.Repeat("a", 100)
+	folded := foldICalLine(line)
+	parts := strings.Split(folded, "\r\n")
+	assert.Equal(t, 2, len(parts))
+	assert.Equal(t, 75, len(parts[0]))
+	assert.Equal(t, line, parts[0]+parts[1][1:])
+}
+
+func TestWeekdayOfMonth(t *testing.T) {
+	monthStart := time.Date(2022, 9, 1, 0, 0, 0, 0, time.Local)
+	assert.Equal(t, time.Date(2022, 9, 28, 0, 0, 0, 0, time.Local), weekdayOfMonth(monthStart, time.Wednesday, 4))
+	assert.Equal(t, time.Date(2022, 9, 1, 0, 0, 0, 0, time.Local), weekdayOfMonth(monthStart, time.Thursday, 1))
+}
+
+func TestNotifyHospitalOperators(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	err := ioutil.WriteFile(filepath.Join(dir, "2022-10-26.txt"), []byte("GSH K4LXF4\n"), 0644)
+	assert.Nil(t, err)
+	callsigns := map[string]Member{"K4LXF4": Member{"Herman", "K4LXF4", "herman@munster.com"}}
+	config := &Config{HospitalDir: dir, HospitalNetTime: NetTime{Start: "19:00"}}
+	mailer := &RecordingMailer{}
+	ledger := &Ledger{DryRun: true}
+	now := time.Date(2022, 10, 20, 0, 0, 0, 0, time.Local)
+
+	err = notifyHospitalOperators(now, callsigns, config, mailer, ledger, "2022-10", false)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(mailer.Messages))
+	assert.Equal(t, []string{"Hospital net 10/26/2022: GSH"}, mailer.Messages[0].GetHeader("Subject"))
+	assert.True(t, ledger.Sent(HospitalAlertKind, "2022-10-26/GSH"))
+
+	var buf bytes.Buffer
+	assert.Nil(t, writeMessage(&buf, mailer.Messages[0]))
+	assert.Contains(t, buf.String(), "text/calendar; method=REQUEST")
+
+	err = notifyHospitalOperators(now, callsigns, config, mailer, ledger, "2022-10", false)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(mailer.Messages))
+}
+
+func TestDispatchHospitalAlertRule(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	configDir := filepath.Join(home, ".net-manager")
+	assert.Nil(t, os.MkdirAll(configDir, 0755))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(configDir, NetcontrolScheduleFileName), []byte(""), 0644))
+	dir := t.TempDir()
+	hospitalLog := filepath.Join(dir, "2022-10-26.txt")
+	assert.Nil(t, ioutil.WriteFile(hospitalLog, []byte("GSH K4LXF4\n"), 0644))
+	config := &Config{HospitalDir: dir, Rules: []Rule{
+		{Message: HospitalAlertKind, Trigger: BeforeWeekdayOfMonthTrigger, Weekday: "wednesday", Week: 4, Offset: 7},
+	}}
+	mailer := &RecordingMailer{}
+	ledger := &Ledger{DryRun: true}
+
+	err := dispatchEmails(time.Date(2022, 10, 20, 8, 0, 0, 0, time.Local), testSignupCallsigns(), config, mailer, ledger, false, false)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(mailer.Messages))
+	assert.Equal(t, []string{"herman@munster.com"}, mailer.Messages[0].GetHeader("To"))
+
+	// An operator assigned later gets the invite on the next day.
+	assert.Nil(t, ioutil.WriteFile(hospitalLog, []byte("GSH K4LXF4\nOCH KJ6ABC\n"), 0644))
+	err = dispatchEmails(time.Date(2022, 10, 21, 8, 0, 0, 0, time.Local), testSignupCallsigns(), config, mailer, ledger, false, false)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(mailer.Messages))
+	assert.Equal(t, []string{"lily@munster.com"}, mailer.Messages[1].GetHeader("To"))
+}
+
+func TestExportCalendar(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	configDir := filepath.Join(home, ".net-manager")
+	assert.Nil(t, os.MkdirAll(configDir, 0755))
+	err := ioutil.WriteFile(filepath.Join(configDir, CityResponsiblityScheduleFileName), []byte("10/4/2022\tSan Jose\n10/11/2022\tCampbell\n"), 0644)
+	assert.Nil(t, err)
+	err = ioutil.WriteFile(filepath.Join(configDir, NetcontrolScheduleFileName), []byte("10/4/2022\tK4LXF4\n"), 0644)
+	assert.Nil(t, err)
+	hospitalDir := t.TempDir()
+	err = ioutil.WriteFile(filepath.Join(hospitalDir, "2022-10-26.txt"), []byte("GSH K4LXF4\n"), 0644)
+	assert.Nil(t, err)
+	callsigns := map[string]Member{"K4LXF4": Member{"Herman", "K4LXF4", "herman@munster.com"}}
+	config := &Config{Station: Station{Call: "N6DVS"}, HospitalDir: hospitalDir, NetTime: NetTime{Start: "19:30"}}
+	now := time.Date(2022, 10, 1, 0, 0, 0, 0, time.Local)
+
+	var buf bytes.Buffer
+	err = exportCalendar(&buf, now, config, callsigns)
+	assert.Nil(t, err)
+	s := buf.String()
+	assert.Contains(t, s, "METHOD:PUBLISH\r\n")
+	assert.Contains(t, s, "UID:net-20221004@n6dvs.net-manager\r\n")
+	assert.Contains(t, s, "SUMMARY:Net: San Jose\\, net control K4LXF4\r\n")
+	assert.Contains(t, s, "SUMMARY:Net: Campbell\\, net control open\r\n")
+	assert.Contains(t, s, "UID:hospital-net-20221026@n6dvs.net-manager\r\n")
+	assert.Contains(t, s, "DTSTART;VALUE=DATE:20221026\r\n")
+	assert.Contains(t, s, "Good Samaritan Hospital: K4LXF4")
+	assert.Equal(t, 3, strings.Count(s, "BEGIN:VEVENT"))
+}
+
+func TestExportCalendarNetTime(t *testing.T) {
+	location, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Skipf("No time zone database: %v", err)
+	}
+	local := time.Local
+	time.Local = location
+	defer func() { time.Local = local }()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	configDir := filepath.Join(home, ".net-manager")
+	assert.Nil(t, os.MkdirAll(configDir, 0755))
+	err = ioutil.WriteFile(filepath.Join(configDir, CityResponsiblityScheduleFileName), []byte("10/4/2022\tSan Jose\n"), 0644)
+	assert.Nil(t, err)
+	err = ioutil.WriteFile(filepath.Join(configDir, NetcontrolScheduleFileName), []byte("10/4/2022\tK4LXF4\n"), 0644)
+	assert.Nil(t, err)
+	config := &Config{NetTime: NetTime{Start: "19:30"}}
+	now := time.Date(2022, 10, 1, 0, 0, 0, 0, time.Local)
+
+	var buf bytes.Buffer
+	err = exportCalendar(&buf, now, config, nil)
+	assert.Nil(t, err)
+	s := buf.String()
+	// 19:30 PDT is the same time as in the invite of the net control.
+	assert.Contains(t, s, "DTSTART:20221005T023000Z\r\n")
+	assert.Contains(t, s, "DTEND:20221005T033000Z\r\n")
+	assert.Contains(t, s, "UID:net-20221004@net-manager\r\n")
+}
+
+func TestExportCalendarWithoutConfig(t *testing.T) {
+	var buf bytes.Buffer
+	err := exportCalendar(&buf, time.Now(), nil, nil)
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, buf.Len())
+}
+
+func TestExportCalendarUnsortedSchedule(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	configDir := filepath.Join(home, ".net-manager")
+	assert.Nil(t, os.MkdirAll(configDir, 0755))
+	err := ioutil.WriteFile(filepath.Join(configDir, CityResponsiblityScheduleFileName), []byte("10/4/2022\tSan Jose\n11/1/2022\tCampbell\n10/11/2022\tSaratoga\n"), 0644)
+	assert.Nil(t, err)
+	err = ioutil.WriteFile(filepath.Join(configDir, NetcontrolScheduleFileName), []byte(""), 0644)
+	assert.Nil(t, err)
+	now := time.Date(2022, 10, 1, 0, 0, 0, 0, time.Local)
+
+	var buf bytes.Buffer
+	err = exportCalendar(&buf, now, &Config{}, nil)
+	assert.Nil(t, err)
+	s := buf.String()
+	assert.Equal(t, 1, strings.Count(s, "UID:net-20221004@net-manager\r\n"))
+	assert.Equal(t, 3, strings.Count(s, "BEGIN:VEVENT"))
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/gomail.v2"
+)
+
+const SendLedgerFileName = "send_ledger.txt"
+
+// Kinds of messages tracked by the send ledger.
+const (
+	NetSignupsKind           = "net-signups"
+	NetControlAlertKind      = "net-control-alert"
+	ReportKind               = "report"
+	HospitalSignupsKind      = "hospital-signups"
+	HospitalAlertKind        = "hospital-alert"
+	NetControlEscalationKind = "net-control-escalation"
+	SubstituteRequestKind    = "substitute-request"
+	// NetControlReminderKind is followed by the name of the reminder stage.
+	NetControlReminderKind = "net-control-reminder"
+)
+
+// LedgerRecord states that a message of the kind was sent for the period.
+// Period is a month prefix like 2022-10 or a net date like 2022-10-04.
+type LedgerRecord struct {
+	Kind   string
+	Period string
+	SentAt time.Time
+}
+
+// Ledger is a persistent list of sent messages. It's stored as a tab separated
+// file in the configuration directory.
+type Ledger struct {
+	fileName string
+	// DryRun ledger only remembers records in memory.
+	DryRun  bool
+	Records []LedgerRecord
+}
+
+func readLedger(fileName string) (*Ledger, error) {
+	l := &Ledger{fileName: fileName, Records: make([]LedgerRecord, 0)}
+	f, err := os.Open(fileName)
+	if os.IsNotExist(err) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open send ledger: %w", err)
+	}
+	defer f.Close()
+	lineReader := bufio.NewReader(f)
+	for {
+		line, _, err := lineReader.ReadLine()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read send ledger: %w", err)
+		}
+		if strings.TrimSpace(string(line)) == "" {
+			continue
+		}
+		tokens := strings.Split(string(line), "\t")
+		if len(tokens) != 3 {
+			return nil, fmt.Errorf("Unknown format of send ledger: %v", string(line))
+		}
+		sentAt, err := time.Parse(time.RFC3339, tokens[2])
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse send ledger: %w", err)
+		}
+		l.Records = append(l.Records, LedgerRecord{tokens[0], tokens[1], sentAt})
+	}
+	return l, nil
+}
+
+func (l *Ledger) Sent(kind, period string) bool {
+	for _, r := range l.Records {
+		if r.Kind == kind && r.Period == period {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *Ledger) Record(kind, period string, sentAt time.Time) error {
+	r := LedgerRecord{kind, period, sentAt}
+	l.Records = append(l.Records, r)
+	if l.DryRun {
+		return nil
+	}
+	err := os.MkdirAll(filepath.Dir(l.fileName), 0755)
+	if err != nil {
+		return fmt.Errorf("Failed to create send ledger directory: %w", err)
+	}
+	f, err := os.OpenFile(l.fileName, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("Failed to open send ledger: %w", err)
+	}
+	defer f.Close()
+	_, err = fmt.Fprintf(f, "%v\t%v\t%v\n", r.Kind, r.Period, r.SentAt.Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("Failed to write send ledger: %w", err)
+	}
+	return nil
+}
+
+// Tracked reports whether the ledger already existed on the date, so that
+// a missing record on this date means that an email was missed.
+func (l *Ledger) Tracked(date time.Time) bool {
+	for _, r := range l.Records {
+		sentAt := r.SentAt.In(date.Location())
+		startDate := time.Date(sentAt.Year(), sentAt.Month(), sentAt.Day(), 0, 0, 0, 0, date.Location())
+		if !date.Before(startDate) {
+			return true
+		}
+	}
+	return false
+}
+
+// shouldSend reports whether the message is due according to the ledger.
+func shouldSend(ledger *Ledger, kind, period string, force bool) bool {
+	if force || !ledger.Sent(kind, period) {
+		return true
+	}
+	fmt.Printf("Not sending %v for %v: already sent. Use -force to send it again.\n", kind, period)
+	return false
+}
+
+func printLedger(l *Ledger) {
+	for _, r := range l.Records {
+		fmt.Printf("%v\t%v\t%v\n", r.SentAt.Format("1/2/2006 15:04"), r.Kind, r.Period)
+	}
+}
+
+// LedgerMailer records every successfully sent message in the ledger.
+type LedgerMailer struct {
+	mailer Mailer
+	ledger *Ledger
+	kind   string
+	period string
+}
+
+func (lm *LedgerMailer) Send(m *gomail.Message) error {
+	err := lm.mailer.Send(m)
+	if err != nil {
+		return err
+	}
+	return lm.ledger.Record(lm.kind, lm.period, time.Now())
+}
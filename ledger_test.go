@@ -0,0 +1,63 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/gomail.v2"
+)
+
+func TestLedgerRecordPersists(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), SendLedgerFileName)
+	ledger, err := readLedger(fileName)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(ledger.Records))
+
+	sentAt := time.Date(2022, 10, 1, 8, 0, 0, 0, time.UTC)
+	assert.Nil(t, ledger.Record(ReportKind, "2022-09", sentAt))
+
+	ledger, err = readLedger(fileName)
+	assert.Nil(t, err)
+	assert.Equal(t, []LedgerRecord{{ReportKind, "2022-09", sentAt}}, ledger.Records)
+	assert.True(t, ledger.Sent(ReportKind, "2022-09"))
+	assert.False(t, ledger.Sent(ReportKind, "2022-10"))
+	assert.False(t, ledger.Sent(NetSignupsKind, "2022-09"))
+}
+
+func TestShouldSend(t *testing.T) {
+	ledger := &Ledger{DryRun: true}
+	assert.Nil(t, ledger.Record(NetSignupsKind, "2022-10", time.Now()))
+
+	assert.False(t, shouldSend(ledger, NetSignupsKind, "2022-10", false))
+	assert.True(t, shouldSend(ledger, NetSignupsKind, "2022-10", true))
+	assert.True(t, shouldSend(ledger, NetSignupsKind, "2022-11", false))
+}
+
+func TestLedgerMailer(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), SendLedgerFileName)
+	ledger, err := readLedger(fileName)
+	assert.Nil(t, err)
+	ledger.DryRun = true
+	recorder := &RecordingMailer{}
+	mailer := &LedgerMailer{recorder, ledger, NetControlAlertKind, "2022-10-04"}
+
+	assert.Nil(t, mailer.Send(gomail.NewMessage()))
+
+	assert.Equal(t, 1, len(recorder.Messages))
+	assert.True(t, ledger.Sent(NetControlAlertKind, "2022-10-04"))
+	_, err = os.Stat(fileName)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestLedgerTracked(t *testing.T) {
+	ledger := &Ledger{DryRun: true}
+	assert.False(t, ledger.Tracked(time.Date(2022, 10, 1, 0, 0, 0, 0, time.Local)))
+
+	ledger.Record(ReportKind, "2022-09", time.Date(2022, 10, 1, 8, 0, 0, 0, time.Local))
+	assert.True(t, ledger.Tracked(time.Date(2022, 10, 1, 0, 0, 0, 0, time.Local)))
+	assert.True(t, ledger.Tracked(time.Date(2022, 11, 1, 0, 0, 0, 0, time.Local)))
+	assert.False(t, ledger.Tracked(time.Date(2022, 9, 30, 0, 0, 0, 0, time.Local)))
+}
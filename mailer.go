@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/gomail.v2"
+)
+
+const (
+	SmtpTransport   = "smtp"
+	OutboxTransport = "outbox"
+)
+
+// Mailer is the single path every outgoing message goes through.
+type Mailer interface {
+	Send(m *gomail.Message) error
+}
+
+func newMailer(config *Config) (Mailer, error) {
+	if config == nil {
+		return &SmtpMailer{}, nil
+	}
+	mail := config.Station.Mail
+	switch mail.Transport {
+	case "", SmtpTransport:
+		return &SmtpMailer{gomail.NewDialer(mail.SmtpHost, mail.Port, mail.Email, mail.Password)}, nil
+	case OutboxTransport:
+		if mail.OutboxDir == "" {
+			return nil, fmt.Errorf("Outbox transport requires outbox-directory")
+		}
+		return &OutboxMailer{Dir: mail.OutboxDir}, nil
+	}
+	return nil, fmt.Errorf("Unknown mail transport: %v", mail.Transport)
+}
+
+// SmtpMailer delivers messages through an smtp server.
+type SmtpMailer struct {
+	dialer *gomail.Dialer
+}
+
+func (s *SmtpMailer) Send(m *gomail.Message) error {
+	if s.dialer == nil {
+		return fmt.Errorf("Smtp server is not configured")
+	}
+	return s.dialer.DialAndSend(m)
+}
+
+// OutboxMailer stores every message as a separate .eml file in a directory
+// instead of delivering it.
+type OutboxMailer struct {
+	Dir   string
+	count int
+}
+
+func (o *OutboxMailer) Send(m *gomail.Message) error {
+	err := os.MkdirAll(o.Dir, 0755)
+	if err != nil {
+		return fmt.Errorf("Failed to create outbox directory: %w", err)
+	}
+	// Another run may write to the same directory within the same second,
+	// so existing files are never overwritten.
+	stamp := time.Now().Format("20060102T150405")
+	var f *os.File
+	var fileName string
+	for {
+		o.count++
+		fileName = filepath.Join(o.Dir, fmt.Sprintf("%v-%03d.eml", stamp, o.count))
+		f, err = os.OpenFile(fileName, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if !os.IsExist(err) {
+			break
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to create outbox file: %w", err)
+	}
+	defer f.Close()
+	log.Tracef("Writing message to %v", fileName)
+	return writeMessage(f, m)
+}
+
+// RecordingMailer keeps sent messages in memory. It is used by tests.
+type RecordingMailer struct {
+	Messages []*gomail.Message
+}
+
+func (r *RecordingMailer) Send(m *gomail.Message) error {
+	r.Messages = append(r.Messages, m)
+	return nil
+}
+
+// writeMessage writes the message in the wire format. gomail drops Bcc
+// recipients from the output, so they are written as a separate header.
+func writeMessage(w io.Writer, m *gomail.Message) error {
+	if bcc := m.GetHeader("Bcc"); len(bcc) > 0 {
+		_, err := fmt.Fprintf(w, "Bcc: %v\r\n", strings.Join(bcc, ", "))
+		if err != nil {
+			return err
+		}
+	}
+	_, err := m.WriteTo(w)
+	return err
+}
+
+// PreviewMailer renders messages in a human readable form instead of sending
+// them. It is used by dry run mode.
+type PreviewMailer struct {
+	Out io.Writer
+}
+
+func newDryRunMailer(dir string) Mailer {
+	if dir != "" {
+		return &OutboxMailer{Dir: dir}
+	}
+	return &PreviewMailer{os.Stdout}
+}
+
+func (p *PreviewMailer) Send(m *gomail.Message) error {
+	var buf bytes.Buffer
+	err := writeMessage(&buf, m)
+	if err != nil {
+		return err
+	}
+	msg, err := mail.ReadMessage(&buf)
+	if err != nil {
+		return fmt.Errorf("Failed to render message: %w", err)
+	}
+	fmt.Fprintf(p.Out, "==== Dry run. Message is not sent ====\n")
+	decoder := &mime.WordDecoder{}
+	for _, h := range []string{"From", "To", "Cc", "Bcc", "Subject"} {
+		v := msg.Header.Get(h)
+		if v == "" {
+			continue
+		}
+		if decoded, err := decoder.DecodeHeader(v); err == nil {
+			v = decoded
+		}
+		fmt.Fprintf(p.Out, "%v: %v\n", h, v)
+	}
+	fmt.Fprintf(p.Out, "Recipients: %v\n", strings.Join(recipients(m), ", "))
+	fmt.Fprintf(p.Out, "\n")
+	err = previewPart(p.Out, textproto.MIMEHeader(msg.Header), msg.Body)
+	fmt.Fprintf(p.Out, "\n==== End of message ====\n\n")
+	return err
+}
+
+func recipients(m *gomail.Message) (r []string) {
+	for _, field := range []string{"To", "Cc", "Bcc"} {
+		r = append(r, m.GetHeader(field)...)
+	}
+	return
+}
+
+// previewPart prints text/plain parts decoded and only summarizes the rest.
+func previewPart(w io.Writer, header textproto.MIMEHeader, body io.Reader) error {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType = "text/plain"
+	}
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("Failed to read message part: %w", err)
+			}
+			err = previewPart(w, part.Header, part)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	data, err := ioutil.ReadAll(decodeBody(header, body))
+	if err != nil {
+		return fmt.Errorf("Failed to read message body: %w", err)
+	}
+	if mediaType == "text/plain" {
+		_, err = w.Write(bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n")))
+		return err
+	}
+	_, params, _ = mime.ParseMediaType(header.Get("Content-Disposition"))
+	if params["filename"] != "" {
+		fmt.Fprintf(w, "\n[%v part %v, %d bytes]\n", mediaType, params["filename"], len(data))
+	} else {
+		fmt.Fprintf(w, "\n[%v part, %d bytes]\n", mediaType, len(data))
+	}
+	return nil
+}
+
+// decodeBody undoes the content transfer encoding of a message part.
+func decodeBody(header textproto.MIMEHeader, body io.Reader) io.Reader {
+	switch strings.ToLower(header.Get("Content-Transfer-Encoding")) {
+	case "quoted-printable":
+		return quotedprintable.NewReader(body)
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, body)
+	}
+	return body
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/gomail.v2"
+)
+
+func TestNewMailerOutbox(t *testing.T) {
+	config := &Config{}
+	config.Station.Mail.Transport = OutboxTransport
+	_, err := newMailer(config)
+	assert.NotNil(t, err)
+
+	config.Station.Mail.OutboxDir = "outbox"
+	mailer, err := newMailer(config)
+	assert.Nil(t, err)
+	assert.Equal(t, "outbox", mailer.(*OutboxMailer).Dir)
+}
+
+func TestNewMailerUnknownTransport(t *testing.T) {
+	config := &Config{}
+	config.Station.Mail.Transport = "pigeon"
+	_, err := newMailer(config)
+	assert.NotNil(t, err)
+}
+
+func TestOutboxMailer(t *testing.T) {
+	dir := t.TempDir()
+	mailer := &OutboxMailer{Dir: filepath.Join(dir, "outbox")}
+	m := gomail.NewMessage()
+	m.SetHeader("From", "n6dvs@example.com")
+	m.SetHeader("To", "list@example.com")
+	m.SetHeader("Bcc", "n6dvs@example.com")
+	m.SetHeader("Subject", "Test")
+	m.SetBody("text/plain", "Hello")
+	assert.Nil(t, mailer.Send(m))
+
+	list, err := filepath.Glob(filepath.Join(dir, "outbox", "*.eml"))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(list))
+	data, err := ioutil.ReadFile(list[0])
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(string(data), "Bcc: n6dvs@example.com\r\n"))
+	assert.Contains(t, string(data), "Subject: Test")
+}
+
+func TestOutboxMailerKeepsMessagesOfOtherRuns(t *testing.T) {
+	dir := t.TempDir()
+	for _, subject := range []string{"First", "Second"} {
+		// Every run has its own mailer.
+		mailer := &OutboxMailer{Dir: dir}
+		m := gomail.NewMessage()
+		m.SetHeader("To", "list@example.com")
+		m.SetHeader("Subject", subject)
+		m.SetBody("text/plain", "Hello")
+		assert.Nil(t, mailer.Send(m))
+	}
+
+	list, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(list))
+}
+
+func TestSendHospitalAnnouncementRecorded(t *testing.T) {
+	dir := t.TempDir()
+	err := ioutil.WriteFile(filepath.Join(dir, "2022-08-24.txt"), []byte("GSH K4LXF4\n"), 0644)
+	assert.Nil(t, err)
+	callsigns := map[string]Member{"K4LXF4": Member{"Herman", "K4LXF4", "herman@munster.com"}}
+	config := &Config{HospitalDir: dir, MailingList: "list@example.com"}
+	mailer := &RecordingMailer{}
+
+	sendHospitalAnnouncement(config, mailer, callsigns, "2022-08")
+
+	assert.Equal(t, 1, len(mailer.Messages))
+	assert.Equal(t, []string{"list@example.com"}, mailer.Messages[0].GetHeader("To"))
+}
+
+func TestNotifyNetControlRecorded(t *testing.T) {
+	callsigns := map[string]Member{"K4LXF4": Member{"Herman", "K4LXF4", "herman@munster.com"}}
+	config := &Config{}
+	mailer := &RecordingMailer{}
+	schedule := []NetcontrolScheduleRecord{
+		{Date: time.Date(2100, 1, 5, 0, 0, 0, 0, time.Now().Location()), Callsign: "k4lxf4"},
+	}
+
+	err := notifyNetControl(time.Date(2099, 12, 31, 0, 0, 0, 0, time.Now().Location()), callsigns, config, mailer, schedule, "")
+
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(mailer.Messages))
+	assert.Equal(t, []string{"herman@munster.com"}, mailer.Messages[0].GetHeader("To"))
+	assert.Equal(t, []string{"Net control 1/5/2100"}, mailer.Messages[0].GetHeader("Subject"))
+}
+
+func TestPreviewMailer(t *testing.T) {
+	var out bytes.Buffer
+	mailer := &PreviewMailer{&out}
+	m := gomail.NewMessage()
+	m.SetHeader("From", "n6dvs@example.com")
+	m.SetHeader("To", "list@example.com")
+	m.SetHeader("Bcc", "n6dvs@example.com")
+	m.SetHeader("Subject", "[SJ-RACES] Net report for Aug 2022")
+	m.SetBody("text/plain", "Hi folks,\n\nGSH\tK4LXF4 = 0.5\n")
+	assert.Nil(t, mailer.Send(m))
+
+	s := out.String()
+	assert.Contains(t, s, "Subject: [SJ-RACES] Net report for Aug 2022\n")
+	assert.Contains(t, s, "Recipients: list@example.com, n6dvs@example.com\n")
+	assert.Contains(t, s, "Hi folks,\n\nGSH\tK4LXF4 = 0.5\n")
+}
@@ -38,15 +38,38 @@ var Hospitals []HospitalDescriptor = []HospitalDescriptor{
 func main() {
 	count := flag.Bool("count", false, "Count checkin numbers")
 	sort := flag.Bool("sort", false, "Sort and print member checkins")
+	follow := flag.Bool("follow", false, "Keep counting checkins as lines are appended to the net log. Use with -count.")
+	acceptSuggestions := flag.Bool("accept-suggestions", false, "Count unknown call signs with a single close member call sign as that member. Use with -count.")
+	listGuests := flag.Bool("guests", false, "List guests that checked into nets of the net log directory.")
+	exportGuestsFile := flag.String("export-guests", "", "Export guests as csv file. Use - for standard output.")
+	console := flag.Bool("console", false, "Log the net interactively into the net log file")
 	timeSheet := flag.Bool("time-sheet", false, "Calculate time sheet for the specified month")
 	sendEmails := flag.Bool("send-emails", false, "Check if it's time to send emails")
 	sendHospitalSignups := flag.Bool("send-hospital-signups", false, "Send hospital net signup announcement. Use month prefix from month prefix argument.")
 	sendNetSignups := flag.Bool("send-net-signups", false, "Send net signup announcement. Use month prefix from month prefix argument to specify month")
 	alertNetControl := flag.Bool("alert-net-control", false, "Alert upcoming net control.")
+	alertHospitalOperators := flag.Bool("alert-hospital-operators", false, "Send calendar invites to operators assigned to hospitals. Use month prefix from month prefix argument.")
 	sendReportFlag := flag.Bool("send-report", false, "Send net report to the chief radio officer.")
 	monthPrefix := flag.String("month-prefix", "", "Month prefix in the format year-mo for drawing time sheet")
 	netLogFile := flag.String("net-log", "net_log.txt", "File with net log")
+	exportCalendarFile := flag.String("export-calendar", "", "Export net schedule as iCalendar file. Use - for standard output.")
+	ingestSignupsMailbox := flag.String("ingest-signups", "", "Read signup replies from this mbox file or Maildir directory and propose schedule additions.")
+	applySignups := flag.Bool("apply", false, "Add signups found by -ingest-signups to the schedule.")
+	checkSchedule := flag.Bool("check-schedule", false, "Report double booked nets and hospitals. Use month prefix from month prefix argument to check only one month.")
+	ackConflicts := flag.Bool("ack-conflicts", false, "Proceed even if the schedule has conflicts.")
+	markNetControlDate := flag.String("mark-net-control", "", "Set confirmation of the net control on this date in the format YYYY-MM-DD.")
+	confirmation := flag.String("confirmation", ConfirmationConfirmed, "Confirmation state for -mark-net-control: pending, confirmed or declined.")
+	requestSubstitutesFlag := flag.Bool("request-substitutes", false, "Ask the substitute pool to run open nets within the next week.")
+	volunteerHoursYear := flag.Int("volunteer-hours", 0, "Print volunteer hours of every member for the year.")
+	certificateDir := flag.String("certificate-dir", "", "Write certificates of members listed by -volunteer-hours to this directory.")
+	certificateHours := flag.Float64("certificate-hours", 10, "Minimum hours for a certificate.")
 	logLevelString := flag.String("debug-level", "info", "Debug level of the application")
+	dryRun := flag.Bool("dry-run", false, "Print emails instead of sending them.")
+	asOf := flag.String("as-of", "", "Pretend that today is this date in the format YYYY-MM-DD.")
+	force := flag.Bool("force", false, "Send emails even if the send ledger says they were already sent.")
+	daemon := flag.Bool("daemon", false, "Keep running and send emails at the configured times of day.")
+	listLedger := flag.Bool("list-ledger", false, "List emails recorded in the send ledger.")
+	dryRunDir := flag.String("dry-run-dir", "", "Store emails in this directory instead of printing them in dry run mode.")
 	flag.Parse()
 
 	logLevel, err := log.ParseLevel(*logLevelString)
@@ -57,6 +80,37 @@ func main() {
 
 	config := readConfig()
 
+	now, err := currentTime(*asOf)
+	if err != nil {
+		fmt.Printf("Failed to parse as-of date: %v\n", err)
+		os.Exit(1)
+	}
+	log.Tracef("Current time: %v", now)
+
+	makeMailer := newMailer
+	if *dryRun {
+		makeMailer = func(*Config) (Mailer, error) {
+			return newDryRunMailer(*dryRunDir), nil
+		}
+	}
+	mailer, err := makeMailer(config)
+	if err != nil {
+		fmt.Printf("Failed to set up mailer: %v\n", err)
+		os.Exit(1)
+	}
+
+	var ledger *Ledger
+	// Only commands that send emails need the send ledger.
+	if *listLedger || *requestSubstitutesFlag || *daemon || *sendEmails || *sendHospitalSignups ||
+		*alertHospitalOperators || *sendNetSignups || *alertNetControl || *sendReportFlag {
+		ledger, err = readLedger(configFilePath(SendLedgerFileName))
+		if err != nil {
+			fmt.Printf("Failed to read send ledger: %v\n", err)
+			os.Exit(1)
+		}
+		ledger.DryRun = *dryRun
+	}
+
 	workingDirectory, err := os.Getwd()
 	if err != nil {
 		fmt.Printf("Failed to retrieve working directory: %v", err)
@@ -69,12 +123,31 @@ func main() {
 	log.Tracef("Sort: %v", *sort)
 	log.Tracef("Time Sheet: %v", timeSheet)
 
-	callSigns, err := readCallsignDB()
+	callSigns, err := loadCallsignDB(config)
 	if err != nil {
 		fmt.Printf("Failed to read call signs: %v", err)
 		os.Exit(1)
 	}
-	if *sort || *count {
+	if *listLedger {
+		printLedger(ledger)
+	} else if *console {
+		c, err := newNetConsole(*netLogFile, callSigns, os.Stdout, time.Now)
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			os.Exit(1)
+		}
+		err = c.run(os.Stdin)
+		if err != nil {
+			fmt.Printf("Failed to log the net: %v\n", err)
+			os.Exit(1)
+		}
+	} else if *count && *follow {
+		err := followCheckins(*netLogFile, callSigns, *acceptSuggestions, followInterval, nil)
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			os.Exit(1)
+		}
+	} else if *sort || *count {
 		netLog, err := readCheckins(*netLogFile)
 		if err != nil {
 			fmt.Printf("Failed to read net log: %v", err)
@@ -83,23 +156,156 @@ func main() {
 		if *sort {
 			sortCheckins(callSigns, netLog)
 		} else if *count {
-			countCheckins(callSigns, netLog)
+			countCheckins(callSigns, netLog, *acceptSuggestions)
 		}
 	} else if *timeSheet {
 		if !validMonthPrefixFormat(monthPrefix) {
 			fmt.Printf("Month prefix is invalid")
 			os.Exit(1)
 		}
-		drawTimeSheet(*monthPrefix, workingDirectory, callSigns)
+		netHours := DefaultNetHours
+		if config != nil {
+			netHours = config.Hours.net()
+		}
+		drawTimeSheet(*monthPrefix, workingDirectory, callSigns, netHours)
+	} else if *listGuests || *exportGuestsFile != "" {
+		if config == nil {
+			fmt.Printf("Guests require a config file with net-log-directory\n")
+			os.Exit(1)
+		}
+		guests, err := readGuests(config.NetDir, callSigns)
+		if err != nil {
+			fmt.Printf("Failed to read guests: %v\n", err)
+			os.Exit(1)
+		}
+		if *exportGuestsFile == "" {
+			fmt.Print(formatGuests(guests))
+		} else {
+			out := os.Stdout
+			if *exportGuestsFile != "-" {
+				out, err = os.Create(*exportGuestsFile)
+				if err != nil {
+					fmt.Printf("Failed to create guest file: %v\n", err)
+					os.Exit(1)
+				}
+				defer out.Close()
+			}
+			err = exportGuests(out, guests)
+			if err != nil {
+				fmt.Printf("Failed to export guests: %v\n", err)
+				os.Exit(1)
+			}
+		}
+	} else if *exportCalendarFile != "" {
+		out := os.Stdout
+		if *exportCalendarFile != "-" {
+			out, err = os.Create(*exportCalendarFile)
+			if err != nil {
+				fmt.Printf("Failed to create calendar file: %v\n", err)
+				os.Exit(1)
+			}
+			defer out.Close()
+		}
+		err := exportCalendar(out, now, config, callSigns)
+		if err != nil {
+			fmt.Printf("Failed to export calendar: %v\n", err)
+			os.Exit(1)
+		}
+	} else if *markNetControlDate != "" {
+		date, err := time.ParseInLocation("2006-01-02", *markNetControlDate, time.Local)
+		if err != nil {
+			fmt.Printf("Failed to parse net date: %v\n", err)
+			os.Exit(1)
+		}
+		err = markNetControl(date, *confirmation)
+		if err != nil {
+			fmt.Printf("Failed to mark net control: %v\n", err)
+			os.Exit(1)
+		}
+	} else if *requestSubstitutesFlag {
+		err := requestSubstitutes(now, callSigns, config, mailer, ledger, *force)
+		if err != nil {
+			fmt.Printf("Failed to request substitutes: %v\n", err)
+			os.Exit(1)
+		}
+	} else if *volunteerHoursYear != 0 {
+		err := yearReport(*volunteerHoursYear, now, config, callSigns, *certificateDir, *certificateHours)
+		if err != nil {
+			fmt.Printf("Failed to compute volunteer hours: %v\n", err)
+			os.Exit(1)
+		}
+	} else if *checkSchedule {
+		if *monthPrefix != "" && !validMonthPrefixFormat(monthPrefix) {
+			fmt.Printf("Month prefix is invalid\n")
+			os.Exit(1)
+		}
+		ncSchedule, err := readNetcontrolSchedule()
+		if err != nil {
+			fmt.Printf("Failed to parse net control schedule: %v\n", err)
+			os.Exit(1)
+		}
+		hospitalDir := ""
+		if config != nil {
+			hospitalDir = config.HospitalDir
+		} else {
+			fmt.Printf("No config file. Checking only the net control schedule.\n")
+		}
+		err = validateSchedule(*monthPrefix, ncSchedule, hospitalDir, callSigns, false)
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("No schedule conflicts found\n")
+	} else if *ingestSignupsMailbox != "" {
+		err := ingestSignups(*ingestSignupsMailbox, *applySignups, *ackConflicts, config, callSigns, now)
+		if err != nil {
+			fmt.Printf("Failed to ingest signups: %v\n", err)
+			os.Exit(1)
+		}
+	} else if *daemon {
+		d, err := newDaemon(config, callSigns, ledger, makeMailer)
+		if err != nil {
+			fmt.Printf("Failed to start daemon: %v\n", err)
+			os.Exit(1)
+		}
+		d.run()
 	} else if *sendEmails {
 		log.Trace("Checking if emails should be sent")
-		dispatchEmails(callSigns, config)
+		err := dispatchEmails(now, callSigns, config, mailer, ledger, *force, *ackConflicts)
+		if err != nil {
+			fmt.Printf("Failed to dispatch emails: %v\n", err)
+			os.Exit(1)
+		}
 	} else if *sendHospitalSignups {
 		if !validMonthPrefixFormat(monthPrefix) {
 			fmt.Printf("Month prefix is invalid")
 			os.Exit(1)
 		}
-		sendHospitalAnnouncement(config, callSigns, *monthPrefix)
+		err := validateSchedule(*monthPrefix, nil, config.HospitalDir, callSigns, *ackConflicts)
+		if err != nil {
+			fmt.Printf("Not sending hospital announcement: %v\n", err)
+			os.Exit(1)
+		}
+		err = sendHospitalAnnouncement(config, &LedgerMailer{mailer, ledger, HospitalSignupsKind, *monthPrefix}, callSigns, *monthPrefix)
+		if err != nil {
+			fmt.Printf("Failed to send hospital announcement: %v\n", err)
+			os.Exit(1)
+		}
+	} else if *alertHospitalOperators {
+		if !validMonthPrefixFormat(monthPrefix) {
+			fmt.Printf("Month prefix is invalid")
+			os.Exit(1)
+		}
+		err := validateSchedule(*monthPrefix, nil, config.HospitalDir, callSigns, *ackConflicts)
+		if err != nil {
+			fmt.Printf("Not notifying hospital operators: %v\n", err)
+			os.Exit(1)
+		}
+		err = notifyHospitalOperators(now, callSigns, config, mailer, ledger, *monthPrefix, *force)
+		if err != nil {
+			fmt.Printf("Failed to notify hospital operators: %v\n", err)
+			os.Exit(1)
+		}
 	} else if *sendNetSignups {
 		if !validMonthPrefixFormat(monthPrefix) {
 			fmt.Printf("Month prefix is invalid")
@@ -112,15 +318,30 @@ func main() {
 		}
 		var year, month int
 		fmt.Sscanf(*monthPrefix, "%d-%d", &year, &month)
-		nextMonthStart := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.Now().Location())
-		callForSignups(nextMonthStart, ncSchedule, config)
+		nextMonthStart := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, now.Location())
+		err = validateSchedule(monthPrefixString(nextMonthStart), ncSchedule, "", callSigns, *ackConflicts)
+		if err != nil {
+			fmt.Printf("Not sending net signups: %v\n", err)
+			os.Exit(1)
+		}
+		err = callForSignups(nextMonthStart, ncSchedule, config, &LedgerMailer{mailer, ledger, NetSignupsKind, monthPrefixString(nextMonthStart)})
+		if err != nil {
+			fmt.Printf("Failed to call for net signups: %v\n", err)
+			os.Exit(1)
+		}
 	} else if *alertNetControl {
 		ncSchedule, err := readNetcontrolSchedule()
 		if err != nil {
 			fmt.Printf("Failed to parse net control schedule: %v\n", err)
 			os.Exit(1)
 		}
-		err = notifyNetControl(callSigns, config, ncSchedule)
+		upcomingNc, err := upcomingNetControl(now, ncSchedule)
+		if err != nil {
+			fmt.Printf("Failed to notify net control: %v\n", err)
+			os.Exit(1)
+		}
+		ledgerMailer := &LedgerMailer{mailer, ledger, NetControlAlertKind, netDateString(upcomingNc.Date)}
+		err = notifyNetControl(now, callSigns, config, ledgerMailer, ncSchedule, "")
 		if err != nil {
 			fmt.Printf("Failed to notify net control: %v\n", err)
 		}
@@ -131,8 +352,12 @@ func main() {
 		}
 		var year, month int
 		fmt.Sscanf(*monthPrefix, "%d-%d", &year, &month)
-		monthStart := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.Now().Location())
-		sendReport(config, callSigns, monthStart)
+		monthStart := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, now.Location())
+		err := sendReport(config, &LedgerMailer{mailer, ledger, ReportKind, monthPrefixString(monthStart)}, callSigns, monthStart, "")
+		if err != nil {
+			fmt.Printf("Failed to send report: %v\n", err)
+			os.Exit(1)
+		}
 	}
 }
 
@@ -141,100 +366,281 @@ type CityResponsibilityRecord struct {
 	City string
 }
 
+// currentTime returns the time the scheduler should act upon. If asOf date
+// is given it returns midnight of that date, so that past and future days
+// can be replayed.
+func currentTime(asOf string) (time.Time, error) {
+	if asOf == "" {
+		return time.Now(), nil
+	}
+	return time.ParseInLocation("2006-01-02", asOf, time.Local)
+}
+
 func weekdayNumber(t time.Time) int {
 	return (t.Day()-1)/7 + 1
 }
 
-func dispatchEmails(callsignDB map[string]Member, config *Config) {
+// dispatchEmails sends emails that are due at the moment according to the
+// dispatch rules. Emails recorded in the ledger are not sent again unless
+// force is set.
+func dispatchEmails(now time.Time, callsignDB map[string]Member, config *Config, mailer Mailer, ledger *Ledger, force bool, ackConflicts bool) error {
+	rules := dispatchRules(config)
+	for _, rule := range rules {
+		if err := rule.validate(); err != nil {
+			return fmt.Errorf("Invalid dispatch rule: %w", err)
+		}
+	}
 	ncSchedule, err := readNetcontrolSchedule()
 	if err != nil {
-		fmt.Printf("Failed to parse net control schedule: %v\n", err)
-		os.Exit(1)
+		return fmt.Errorf("Failed to parse net control schedule: %w", err)
 	}
 
-	tss, nextMonthStart := timeToSendNetSignups()
-	if tss {
-		callForSignups(nextMonthStart, ncSchedule, config)
+	for _, rule := range rules {
+		for _, event := range rule.events(now, ledger, ncSchedule) {
+			onTime := equalByDate(event.Due, now)
+			if rule.Message == HospitalAlertKind {
+				// Invites are recorded per hospital, so operators assigned
+				// since the last firing get their invites now.
+				monthPrefix := monthPrefixString(event.Target)
+				err := validateSchedule(monthPrefix, nil, config.HospitalDir, callsignDB, ackConflicts)
+				if err == nil {
+					err = notifyHospitalOperators(now, callsignDB, config, mailer, ledger, monthPrefix, force && onTime)
+				}
+				if err != nil {
+					fmt.Printf("Failed to send %v: %v\n", rule.Message, err)
+				}
+				continue
+			}
+			period, err := messagePeriod(rule.Message, event.Target, ncSchedule)
+			if err != nil {
+				fmt.Printf("Failed to send %v: %v\n", rule.Message, err)
+				continue
+			}
+			if !onTime && (ledger.Sent(rule.Message, period) || messageExpired(rule.Message, period, now)) {
+				continue
+			}
+			if !shouldSend(ledger, rule.Message, period, force && onTime) {
+				continue
+			}
+			ledgerMailer := &LedgerMailer{mailer, ledger, rule.Message, period}
+			err = sendMessage(rule.Message, event, lateNote(now, event.Due), callsignDB, config, ledgerMailer, ncSchedule, ackConflicts)
+			if err != nil {
+				fmt.Printf("Failed to send %v: %v\n", rule.Message, err)
+			}
+		}
 	}
+	return sendReminders(now, callsignDB, config, mailer, ledger, ncSchedule, force)
+}
 
-	now := time.Now()
-	if now.Weekday() == time.Sunday {
-		err := notifyNetControl(callsignDB, config, ncSchedule)
+// messagePeriod returns the ledger period of the message fired for the
+// target date.
+func messagePeriod(kind string, target time.Time, ncSchedule []NetcontrolScheduleRecord) (string, error) {
+	switch kind {
+	case NetControlAlertKind:
+		upcomingNc, err := upcomingNetControl(target, ncSchedule)
 		if err != nil {
-			fmt.Printf("Failed to notify net control: %v\n", err)
+			return "", err
 		}
+		return netDateString(upcomingNc.Date), nil
+	case NetControlEscalationKind, SubstituteRequestKind:
+		return netDateString(target), nil
+	case ReportKind:
+		return monthPrefixString(previousMonth(target)), nil
 	}
-	if now.Day() == 1 {
-		log.Trace("Sending time sheet\n")
-		now := time.Now()
-		previousMonthTime := time.Date(now.Year(), now.Month()-1, 1, 0, 0, 0, 0, now.Location())
-		sendReport(config, callsignDB, previousMonthTime)
+	return monthPrefixString(target), nil
+}
+
+// messageExpired reports whether it's too late to send the message.
+func messageExpired(kind, period string, now time.Time) bool {
+	if kind != NetControlAlertKind {
+		return false
 	}
-	if weekdayNumber(upcomingWednesday(now)) == 4 {
-		monthPrefix := fmt.Sprintf("%d-%02d", now.Year(), now.Month())
-		sendHospitalAnnouncement(config, callsignDB, monthPrefix)
+	netDate, err := time.ParseInLocation("2006-01-02", period, now.Location())
+	return err != nil || !netDate.After(now)
+}
+
+func sendMessage(kind string, event RuleEvent, lateNote string, callsignDB map[string]Member, config *Config, mailer Mailer, ncSchedule []NetcontrolScheduleRecord, ackConflicts bool) error {
+	switch kind {
+	case NetSignupsKind:
+		err := validateSchedule(monthPrefixString(event.Target), ncSchedule, "", callsignDB, ackConflicts)
+		if err != nil {
+			return err
+		}
+		return callForSignups(event.Target, ncSchedule, config, mailer)
+	case NetControlAlertKind:
+		return notifyNetControl(event.Target, callsignDB, config, mailer, ncSchedule, lateNote)
+	case NetControlEscalationKind:
+		return escalateNetControl(event.Target, callsignDB, config, mailer, ncSchedule)
+	case SubstituteRequestKind:
+		citySchedule, err := readCityResponsibilitySchedule()
+		if err != nil {
+			return fmt.Errorf("Failed to read city responsibility schedule: %w", err)
+		}
+		return requestSubstitute(event.Target, callsignDB, config, mailer, ncSchedule, citySchedule)
+	case ReportKind:
+		log.Trace("Sending time sheet\n")
+		return sendReport(config, mailer, callsignDB, previousMonth(event.Target), lateNote)
+	case HospitalSignupsKind:
+		err := validateSchedule(monthPrefixString(event.Target), nil, config.HospitalDir, callsignDB, ackConflicts)
+		if err != nil {
+			return err
+		}
+		return sendHospitalAnnouncement(config, mailer, callsignDB, monthPrefixString(event.Target))
 	}
+	return fmt.Errorf("Unknown message: %v", kind)
+}
+
+func previousMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month()-1, 1, 0, 0, 0, 0, t.Location())
 }
 
-func upcomingWednesday(t time.Time) time.Time {
-	daysToWednesday := int(time.Wednesday - t.Weekday())
-	if daysToWednesday <= 0 {
-		daysToWednesday += 7
+// lateNote returns a note for emails that should have been sent on due date.
+func lateNote(now time.Time, due time.Time) string {
+	if equalByDate(now, due) {
+		return ""
 	}
-	return time.Date(t.Year(), t.Month(), t.Day()+daysToWednesday, 0, 0, 0, 0, t.Location())
+	return fmt.Sprintf("Sorry, this email is late. It was due on %v.\n\n", due.Format("1/2/2006"))
+}
+
+func monthPrefixString(t time.Time) string {
+	return fmt.Sprintf("%d-%02d", t.Year(), t.Month())
+}
+
+func netDateString(t time.Time) string {
+	return t.Format("2006-01-02")
 }
 
-func sendHospitalAnnouncement(config *Config, callsignDB map[string]Member, monthPrefix string) {
+func sendHospitalAnnouncement(config *Config, mailer Mailer, callsignDB map[string]Member, monthPrefix string) error {
 	if config.MailingList == "" {
 		log.Errorf("Empty mailing list config. Not sending hospital announcement.")
-		return
+		return nil
 	}
-	d := gomail.NewDialer(config.Station.Mail.SmtpHost, config.Station.Mail.Port, config.Station.Mail.Email, config.Station.Mail.Password)
 
 	m := gomail.NewMessage()
 	m.SetHeader("From", config.Station.Mail.Email)
 	m.SetHeader("To", config.MailingList)
 
 	m.SetHeader("Subject", fmt.Sprintf("[SJ-RACES] Hospital Net next Wednesday, 7pm"))
-	bodyText := ""
-	bodyText += "Hi folks,\n\n"
-	bodyText += "Hospital net is next week.\n"
-	bodyText += "Please sign up for one of the hospitals.\n"
-	bodyText += "In order to sign up you need to reply to this email with your callsign and the hospital of choice.\n"
-	bodyText += "\n"
 
 	schedule, err := readHospitalSchedule(monthPrefix, config.HospitalDir, callsignDB)
 
 	if err != nil {
-		log.Errorf("Failed to send email: %v", err)
-		os.Exit(1)
+		return fmt.Errorf("Failed to read hospital schedule: %w", err)
 	}
 
-	longestName := longestHospitalName()
+	data := MessageData{Station: config.Station, HospitalNameWidth: longestHospitalName()}
+	data.Month, _ = time.ParseInLocation("2006-01", monthPrefix, time.Local)
 	for _, h := range Hospitals {
-		bodyText += h.FullName + spacer(longestName-len(h.FullName)+10)
-		if s, ok := schedule[h.Acronym]; ok {
-			bodyText += s.Callsign + "\n"
-		} else {
-			bodyText += "Available!\n"
-		}
+		data.Hospitals = append(data.Hospitals, HospitalAssignment{h, schedule[h.Acronym]})
+	}
+	err = setBody(m, HospitalSignupsKind, data)
+	if err != nil {
+		return err
 	}
-	bodyText += "\n"
-	bodyText += "Net control is Regional San Jose (RSJ)\n"
-	bodyText += "\n"
-	bodyText += fmt.Sprintf("\n\n%v", config.Station.Signature)
 
-	m.SetBody("text/plain", bodyText)
+	if err := mailer.Send(m); err != nil {
+		return fmt.Errorf("Failed to send email: %w", err)
+	}
+	return nil
+}
 
-	if err := d.DialAndSend(m); err != nil {
-		log.Errorf("Failed to send email: %v", err)
-		os.Exit(1)
+// notifyHospitalOperators emails every operator assigned to a hospital in the
+// month's hospital net log. Operators that were already notified are skipped
+// unless force is set, so it can be run again after new assignments.
+func notifyHospitalOperators(now time.Time, callsignDB map[string]Member, config *Config, mailer Mailer, ledger *Ledger, monthPrefix string, force bool) error {
+	schedule, err := readHospitalSchedule(monthPrefix, config.HospitalDir, callsignDB)
+	if err != nil {
+		return fmt.Errorf("Failed to read hospital schedule: %w", err)
+	}
+	netDate, err := hospitalNetDate(monthPrefix, config.HospitalDir)
+	if err != nil {
+		return err
+	}
+	for _, h := range Hospitals {
+		member, ok := schedule[h.Acronym]
+		if !ok {
+			continue
+		}
+		period := netDateString(netDate) + "/" + h.Acronym
+		if !shouldSend(ledger, HospitalAlertKind, period, force) {
+			continue
+		}
+		if member.Email == "" {
+			fmt.Printf("Operator %v has empty email\n", member.Callsign)
+			continue
+		}
+		m := gomail.NewMessage()
+		m.SetHeader("From", config.Station.Mail.Email)
+		m.SetHeader("To", member.Email)
+		m.SetHeader("Bcc", config.Station.Mail.Email)
+		m.SetHeader("Subject", fmt.Sprintf("Hospital net %v: %v", netDate.Format("1/2/2006"), h.Acronym))
+		err = setBody(m, HospitalAlertKind, MessageData{
+			Station:  config.Station,
+			Month:    time.Date(netDate.Year(), netDate.Month(), 1, 0, 0, 0, 0, netDate.Location()),
+			Member:   member,
+			NetDate:  netDate,
+			Hospital: h,
+		})
+		if err != nil {
+			return err
+		}
+		if config.HospitalNetTime.configured() {
+			start, end, err := config.HospitalNetTime.span(netDate)
+			if err != nil {
+				return fmt.Errorf("Failed to parse hospital net time: %w", err)
+			}
+			err = attachInvite(m, CalendarEvent{
+				UID:       eventUID("hospital-net", netDate, config),
+				Start:     start,
+				End:       end,
+				Summary:   "Hospital Net: " + h.FullName,
+				Location:  h.FullName,
+				Organizer: config.Station.Mail.Email,
+				Attendees: []Member{member},
+			}, now)
+			if err != nil {
+				return err
+			}
+		}
+		err = (&LedgerMailer{mailer, ledger, HospitalAlertKind, period}).Send(m)
+		if err != nil {
+			return fmt.Errorf("Failed to send email: %w", err)
+		}
 	}
+	return nil
 }
 
-func spacer(n int) string {
-	s := "                                               "
-	return s[0:n]
+// hospitalNetDate returns the date of the hospital net from the name of the
+// month's hospital net log. Without a log it's the fourth Wednesday.
+func hospitalNetDate(monthPrefix, logDirectory string) (time.Time, error) {
+	list, err := filepath.Glob(filepath.Join(logDirectory, monthPrefix) + "*")
+	if err != nil {
+		return time.Time{}, err
+	}
+	for _, f := range list {
+		base := filepath.Base(f)
+		if len(base) < 10 {
+			continue
+		}
+		date, err := time.ParseInLocation("2006-01-02", base[:10], time.Local)
+		if err == nil {
+			return date, nil
+		}
+	}
+	monthStart, err := time.ParseInLocation("2006-01", monthPrefix, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Failed to find hospital net date: %w", err)
+	}
+	return weekdayOfMonth(monthStart, time.Wednesday, 4), nil
+}
+
+// weekdayOfMonth returns the week-th weekday of the month.
+func weekdayOfMonth(monthStart time.Time, weekday time.Weekday, week int) time.Time {
+	days := int(weekday - monthStart.Weekday())
+	if days < 0 {
+		days += 7
+	}
+	return time.Date(monthStart.Year(), monthStart.Month(), 1+days+7*(week-1), 0, 0, 0, 0, monthStart.Location())
 }
 
 func longestHospitalName() (l int) {
@@ -267,7 +673,21 @@ func readHospitalSchedule(monthPrefix, logDirectory string, callsignDB map[strin
 }
 
 func readHospitalAssignments(logFileName string, callsignDB map[string]Member) (res map[string]Member, err error) {
+	assignments, err := readHospitalLog(logFileName, callsignDB)
+	if err != nil {
+		return nil, err
+	}
 	res = make(map[string]Member)
+	for _, a := range assignments {
+		res[a.Acronym] = a.Member
+	}
+	return res, nil
+}
+
+// readHospitalLog returns assignments of the hospital net log in the order of
+// the file. Unlike readHospitalAssignments it keeps duplicated hospitals.
+func readHospitalLog(logFileName string, callsignDB map[string]Member) (res []HospitalAssignment, err error) {
+	res = make([]HospitalAssignment, 0)
 	f, err := os.Open(logFileName)
 	if err != nil {
 		return nil, err
@@ -286,29 +706,43 @@ func readHospitalAssignments(logFileName string, callsignDB map[string]Member) (
 		}
 		log.Tracef("Read checkin line: %v", line)
 		s := strings.TrimSpace(strings.ToUpper(string(line)))
-		ps := strings.Split(s, " ")
+		ps := strings.Fields(s)
+		if len(ps) > 0 {
+			if _, err := parseTimeOfDay(ps[0]); err == nil {
+				ps = ps[1:]
+				if len(ps) == 0 {
+					// Time marks only matter for hours.
+					continue
+				}
+			}
+		}
 		if len(ps) != 2 {
 			return nil, fmt.Errorf("Unknown format of hospital file: %v", s)
 		}
-		member, ok := callsignDB[ps[1]]
+		member, ok := lookupMember(callsignDB, ps[1])
 		if !ok {
 			return nil, fmt.Errorf("Unknown callsign: %v", ps[1])
 		}
-		res[ps[0]] = member
+		hospital := HospitalDescriptor{Acronym: ps[0]}
+		for _, h := range Hospitals {
+			if h.Acronym == ps[0] {
+				hospital = h
+			}
+		}
+		res = append(res, HospitalAssignment{hospital, member})
 	}
 	return res, nil
 }
 
-func sendReport(config *Config, callsigns map[string]Member, previousMonthTime time.Time) {
+func sendReport(config *Config, mailer Mailer, callsigns map[string]Member, previousMonthTime time.Time, lateNote string) error {
 	monthPrefix := fmt.Sprintf("%d-%02d", previousMonthTime.Year(), previousMonthTime.Month())
-	netString, netHours, err := drawTimeSheetString(monthPrefix, config.NetDir, callsigns)
-	hospitalHours, err := hospitalHoursCount(monthPrefix, config.HospitalDir, callsigns)
+	netRows, netHours, err := timeSheetRows(monthPrefix, config.NetDir, callsigns, config.Hours.net())
+	netString := formatTimeSheet(netRows, netHours)
+	hospitalHours, hospitalMethod, err := hospitalHoursCount(monthPrefix, config.HospitalDir, callsigns, config.Hours.hospital())
 	log.Tracef("Report to be sent: \n%v\n, %v\n", netString, err)
 	log.Tracef("Hospital Net: %0.3f, %v\n", hospitalHours, err)
 	log.Tracef("Total Hours: %0.3f, %v\n", hospitalHours+netHours, err)
 
-	d := gomail.NewDialer(config.Station.Mail.SmtpHost, config.Station.Mail.Port, config.Station.Mail.Email, config.Station.Mail.Password)
-
 	m := gomail.NewMessage()
 	m.SetHeader("From", config.Station.Mail.Email)
 	m.SetHeader("To", config.TimeReport.MainMail)
@@ -318,25 +752,31 @@ func sendReport(config *Config, callsigns map[string]Member, previousMonthTime t
 	monthString := previousMonthTime.Format("Jan 2006")
 	m.SetHeader("Bcc", config.Station.Mail.Email)
 	m.SetHeader("Subject", fmt.Sprintf("[SJ-RACES] Net report for %v", monthString))
-	bodyText := ""
-	bodyText += "Hi folks,\n\n"
-	bodyText += fmt.Sprintf("Here is net control statistics for %v:\n\n", monthString)
-	bodyText += netString
-	bodyText += "\n"
-	bodyText += fmt.Sprintf("Hospital Net: %0.3f\n\n", hospitalHours)
-	bodyText += fmt.Sprintf("Total Hours: %0.3f\n", hospitalHours+netHours)
-	bodyText += fmt.Sprintf("\n\n%v", config.Station.Signature)
-
-	m.SetBody("text/plain", bodyText)
-
-	if err := d.DialAndSend(m); err != nil {
-		log.Errorf("Failed to send email: %v", err)
-		os.Exit(1)
+	err = setBody(m, ReportKind, MessageData{
+		Station:        config.Station,
+		Month:          previousMonthTime,
+		LateNote:       lateNote,
+		TimeSheet:      netString,
+		TimeSheetRows:  netRows,
+		NetHours:       netHours,
+		HospitalHours:  hospitalHours,
+		HospitalMethod: hospitalMethod,
+		TotalHours:     hospitalHours + netHours,
+		HoursLegend:    hoursLegend(config.Hours),
+	})
+	if err != nil {
+		return err
 	}
+
+	if err := mailer.Send(m); err != nil {
+		return fmt.Errorf("Failed to send email: %w", err)
+	}
+	return nil
 }
 
-func timeToSendNetSignups() (bool, time.Time) {
-	now := time.Now()
+// timeToSendNetSignups reports whether the next month starts in less than
+// days.
+func timeToSendNetSignups(now time.Time, days int) (bool, time.Time) {
 	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
 	var nextMonthStart time.Time
 	if now.Day() < 15 {
@@ -348,25 +788,23 @@ func timeToSendNetSignups() (bool, time.Time) {
 
 	fmt.Printf("Distance %v\n", distance)
 	fmt.Printf("NextMonthStart %v\n", nextMonthStart)
-	return distance < time.Hour*24*10, nextMonthStart
+	return distance < time.Hour*24*time.Duration(days), nextMonthStart
 }
 
-func callForSignups(nextMonthStart time.Time, ncSchedule []NetcontrolScheduleRecord, config *Config) {
+func callForSignups(nextMonthStart time.Time, ncSchedule []NetcontrolScheduleRecord, config *Config, mailer Mailer) error {
 	if config.MailingList == "" {
 		log.Errorf("Empty mailing list config. Not sending Tuesday net announcement.")
-		return
+		return nil
 	}
 	citySchedule, err := readCityResponsibilitySchedule()
 	if err != nil {
-		fmt.Printf("Failed to read city responsibility schedule: %v\n", err)
-		os.Exit(1)
+		return fmt.Errorf("Failed to read city responsibility schedule: %w", err)
 	}
 
 	fmt.Printf("Parsed city responsibility schedule: %v\n", citySchedule)
 
 	if !monthCityComplete(nextMonthStart, citySchedule) {
-		fmt.Printf("Next month city schedule is incomplete. Add more records.\n")
-		os.Exit(1)
+		return fmt.Errorf("Next month city schedule is incomplete. Add more records.")
 	}
 	monthFull, ms := monthSchedule(nextMonthStart, ncSchedule, citySchedule)
 	fmt.Printf("Month schedule: %v\n", ms)
@@ -380,30 +818,25 @@ func callForSignups(nextMonthStart time.Time, ncSchedule []NetcontrolScheduleRec
 			fmt.Printf("%v\t%v\t%v\n", nc.Date.Format("1/2/2006"), nc.City, nc.Callsign)
 		}
 
-		d := gomail.NewDialer(config.Station.Mail.SmtpHost, config.Station.Mail.Port, config.Station.Mail.Email, config.Station.Mail.Password)
-
 		m := gomail.NewMessage()
 		m.SetHeader("From", config.Station.Mail.Email)
 		m.SetHeader("To", config.MailingList)
 		m.SetHeader("Subject", fmt.Sprintf("[SJ-RACES] SJ RACES Net Control for %v", nextMonthStart.Format("Jan 2006")))
-		bodyText := ""
-		bodyText += "Hi,\n\n"
-		bodyText += "Net control positions are open.\n\n"
-		bodyText += "Here is the schedule right now:\n"
-		for _, nc := range ms {
-			bodyText += fmt.Sprintf("%v\t%v\t%v\n", nc.Date.Format("1/2/2006"), nc.City, nc.Callsign)
+		err = setBody(m, NetSignupsKind, MessageData{
+			Station:  config.Station,
+			Month:    nextMonthStart,
+			Schedule: ms,
+		})
+		if err != nil {
+			return err
 		}
-		bodyText += "\nSimply respond to this email with your name, callsign and date to signup for a net control position.\n"
-		bodyText += fmt.Sprintf("\n\n%v", config.Station.Signature)
-		m.SetBody("text/plain", bodyText)
 
-		if err := d.DialAndSend(m); err != nil {
-			fmt.Printf("Failed to send email: %v", err)
-			os.Exit(1)
+		if err := mailer.Send(m); err != nil {
+			return fmt.Errorf("Failed to send email: %w", err)
 		}
 
 	}
-
+	return nil
 }
 
 func monthCityComplete(monthStart time.Time, citySchedule []CityResponsibilityRecord) bool {
@@ -446,9 +879,14 @@ func monthSchedule(monthStart time.Time, ncSchedule []NetcontrolScheduleRecord,
 	return monthFull, schedule
 }
 
+// NetcontrolScheduleRecord is a line of the net control schedule. The
+// optional third column is the confirmation state, pending by default. The
+// optional fourth column is the call sign of the alternate net control.
 type NetcontrolScheduleRecord struct {
-	Date     time.Time
-	Callsign string
+	Date         time.Time
+	Callsign     string
+	Confirmation string
+	Alternate    string
 }
 
 type NetcontrolSchedule []NetcontrolScheduleRecord
@@ -473,18 +911,22 @@ func equalByDate(a, b time.Time) bool {
 	return a.Year() == b.Year() && a.Month() == b.Month() && a.Day() == b.Day()
 }
 
-func notifyNetControl(callsignDB map[string]Member, config *Config, netcontrolSchedule []NetcontrolScheduleRecord) error {
-	now := time.Now()
-
+func upcomingNetControl(now time.Time, netcontrolSchedule []NetcontrolScheduleRecord) (NetcontrolScheduleRecord, error) {
 	sort.Sort(NetcontrolSchedule(netcontrolSchedule))
 	recordIndex := sort.Search(len(netcontrolSchedule), func(i int) bool {
 		return now.Before(netcontrolSchedule[i].Date)
 	})
 	if recordIndex >= len(netcontrolSchedule) {
-		return fmt.Errorf("No upcoming net control found")
+		return NetcontrolScheduleRecord{}, fmt.Errorf("No upcoming net control found")
 	}
+	return netcontrolSchedule[recordIndex], nil
+}
 
-	upcomingNc := netcontrolSchedule[recordIndex]
+func notifyNetControl(now time.Time, callsignDB map[string]Member, config *Config, mailer Mailer, netcontrolSchedule []NetcontrolScheduleRecord, lateNote string) error {
+	upcomingNc, err := upcomingNetControl(now, netcontrolSchedule)
+	if err != nil {
+		return err
+	}
 
 	ncCallsign := strings.ToUpper(upcomingNc.Callsign)
 
@@ -495,17 +937,53 @@ func notifyNetControl(callsignDB map[string]Member, config *Config, netcontrolSc
 	}
 	fmt.Printf("Sending email to: %v\n", ncEmail)
 
-	d := gomail.NewDialer(config.Station.Mail.SmtpHost, config.Station.Mail.Port, config.Station.Mail.Email, config.Station.Mail.Password)
-
 	m := gomail.NewMessage()
 	m.SetHeader("From", config.Station.Mail.Email)
 	m.SetHeader("To", ncEmail)
+	attendees := []Member{callsignDB[ncCallsign]}
+	alternate := callsignDB[strings.ToUpper(upcomingNc.Alternate)]
+	if upcomingNc.Alternate != "" {
+		if alternate.Email == "" {
+			fmt.Printf("Alternate net control %v has empty email\n", strings.ToUpper(upcomingNc.Alternate))
+		} else {
+			fmt.Printf("Sending email to alternate: %v\n", alternate.Email)
+			m.SetHeader("Cc", alternate.Email)
+			attendees = append(attendees, alternate)
+		}
+	}
 	dateString := upcomingNc.Date.Format("1/2/2006")
 	m.SetHeader("Bcc", config.Station.Mail.Email)
 	m.SetHeader("Subject", fmt.Sprintf("Net control %v", dateString))
-	m.SetBody("text/plain", fmt.Sprintf("Hi %s,\n\nThank you for volunteering. Could you please confirm that you are still comfortable running the net on %v\n\nThanks, Victor.", callsignDB[ncCallsign].Name, dateString))
+	err = setBody(m, NetControlAlertKind, MessageData{
+		Station:   config.Station,
+		Month:     time.Date(upcomingNc.Date.Year(), upcomingNc.Date.Month(), 1, 0, 0, 0, 0, upcomingNc.Date.Location()),
+		LateNote:  lateNote,
+		Member:    callsignDB[ncCallsign],
+		Alternate: alternate,
+		NetDate:   upcomingNc.Date,
+	})
+	if err != nil {
+		return err
+	}
+	if config.NetTime.configured() {
+		start, end, err := config.NetTime.span(upcomingNc.Date)
+		if err != nil {
+			return fmt.Errorf("Failed to parse net time: %w", err)
+		}
+		err = attachInvite(m, CalendarEvent{
+			UID:       eventUID("net", upcomingNc.Date, config),
+			Start:     start,
+			End:       end,
+			Summary:   "Net control: " + ncCallsign,
+			Organizer: config.Station.Mail.Email,
+			Attendees: attendees,
+		}, now)
+		if err != nil {
+			return err
+		}
+	}
 
-	if err := d.DialAndSend(m); err != nil {
+	if err := mailer.Send(m); err != nil {
 		return fmt.Errorf("Failed to send email: %w", err)
 	}
 
@@ -528,13 +1006,27 @@ func readNetcontrolSchedule() ([]NetcontrolScheduleRecord, error) {
 			break
 		}
 		tokens := bytes.Split(line, []byte("\t"))
+		if len(tokens) < 2 {
+			return nil, fmt.Errorf("Unknown format of netcontrol schedule: %v", string(line))
+		}
 		date, err := time.Parse("1/2/2006", string(bytes.TrimSpace(tokens[0])))
 		if err != nil {
 			return nil, fmt.Errorf("Failed to parse netcontrol schedule: %w", err)
 		}
 		date = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, now.Location())
-		callsign := string(bytes.TrimSpace(tokens[1]))
-		records = append(records, NetcontrolScheduleRecord{date, callsign})
+		callsign := normalizeCallsign(string(tokens[1]))
+		confirmation := ConfirmationPending
+		if len(tokens) > 2 {
+			confirmation, err = parseConfirmation(string(bytes.TrimSpace(tokens[2])))
+			if err != nil {
+				return nil, fmt.Errorf("Failed to parse netcontrol schedule: %w", err)
+			}
+		}
+		alternate := ""
+		if len(tokens) > 3 {
+			alternate = normalizeCallsign(string(tokens[3]))
+		}
+		records = append(records, NetcontrolScheduleRecord{date, callsign, confirmation, alternate})
 	}
 	return records, nil
 }
@@ -593,8 +1085,8 @@ func readCallsignDB() (r map[string]Member, err error) {
 	return
 }
 
-func readCheckins(netLog string) (r chan string, err error) {
-	r = make(chan string)
+func readCheckins(netLog string) (r chan CheckinRecord, err error) {
+	r = make(chan CheckinRecord)
 	f, err := os.Open(netLog)
 	if err != nil {
 		return nil, err
@@ -611,8 +1103,7 @@ func readCheckins(netLog string) (r chan string, err error) {
 				break
 			}
 			log.Tracef("Read checkin line: %v", line)
-			s := strings.ToUpper(string(line))
-			r <- strings.TrimSpace(s)
+			r <- parseCheckinLine(string(line))
 		}
 	}()
 	return r, nil
@@ -637,7 +1128,8 @@ type CheckinItemVisitor interface {
 }
 
 type DupCheckin struct {
-	s string
+	s      string
+	record CheckinRecord
 }
 
 func (d *DupCheckin) accept(v CheckinItemVisitor) {
@@ -645,7 +1137,8 @@ func (d *DupCheckin) accept(v CheckinItemVisitor) {
 }
 
 type MemberCheckin struct {
-	s string
+	s      string
+	record CheckinRecord
 }
 
 func (d *MemberCheckin) accept(v CheckinItemVisitor) {
@@ -660,34 +1153,70 @@ func (d *SectionCheckin) accept(v CheckinItemVisitor) {
 }
 
 type UnknownCheckin struct {
-	s string
+	s      string
+	record CheckinRecord
+	// suggestions are member call signs that s was probably meant to be.
+	suggestions []string
 }
 
 func (d *UnknownCheckin) accept(v CheckinItemVisitor) {
 	v.visitUnknown(d)
 }
 
-func annotateCheckins(callSigns map[string]Member, netLog <-chan string) <-chan CheckinItem {
-	confirmedMembers := make(map[string]struct{})
-	sectionMembers := make(map[string]struct{})
+// CheckinAnnotator annotates net log records one at a time.
+type CheckinAnnotator struct {
+	callSigns        map[string]Member
+	confirmedMembers map[string]struct{}
+	// acceptSuggestions treats unknown call signs with a single suggestion
+	// as checkins of the suggested member.
+	acceptSuggestions bool
+}
 
+func newCheckinAnnotator(callSigns map[string]Member) *CheckinAnnotator {
+	return &CheckinAnnotator{callSigns: callSigns, confirmedMembers: make(map[string]struct{})}
+}
+
+// annotate returns the item of the record or nil for time marks.
+func (a *CheckinAnnotator) annotate(record CheckinRecord) CheckinItem {
+	v := record.Callsign
+	if record.Section() {
+		return &SectionCheckin{}
+	}
+	if v == "" {
+		// Time marks are neither checkins nor sections.
+		return nil
+	}
+	member, ok := lookupMember(a.callSigns, v)
+	if !ok && record.Location != "" {
+		// Tactical call signs may have two words like GSH NET.
+		member, ok = lookupMember(a.callSigns, v+" "+strings.Fields(record.Location)[0])
+	}
+	if ok {
+		v = member.Callsign
+	} else {
+		suggestions := suggestCallsigns(v, a.callSigns)
+		if !a.acceptSuggestions || len(suggestions) != 1 {
+			return &UnknownCheckin{v, record, suggestions}
+		}
+		v = suggestions[0]
+	}
+	if _, ok := a.confirmedMembers[v]; ok {
+		return &DupCheckin{v, record}
+	}
+	a.confirmedMembers[v] = struct{}{}
+	return &MemberCheckin{v, record}
+}
+
+func annotateCheckins(callSigns map[string]Member, netLog <-chan CheckinRecord) <-chan CheckinItem {
+	return newCheckinAnnotator(callSigns).annotateAll(netLog)
+}
+
+func (a *CheckinAnnotator) annotateAll(netLog <-chan CheckinRecord) <-chan CheckinItem {
 	r := make(chan CheckinItem)
 	go func() {
-		for v := range netLog {
-			if v == "" {
-				r <- &SectionCheckin{}
-			} else {
-				if _, ok := callSigns[v]; ok {
-					if _, ok := confirmedMembers[v]; ok {
-						r <- &DupCheckin{v}
-					} else {
-						r <- &MemberCheckin{v}
-						sectionMembers[v] = struct{}{}
-					}
-					confirmedMembers[v] = struct{}{}
-				} else {
-					r <- &UnknownCheckin{v}
-				}
+		for record := range netLog {
+			if item := a.annotate(record); item != nil {
+				r <- item
 			}
 		}
 		r <- &SectionCheckin{}
@@ -699,16 +1228,34 @@ func annotateCheckins(callSigns map[string]Member, netLog <-chan string) <-chan
 type CheckinCounter struct {
 	sectionCount int
 	totalCount   int
+	trafficCount int
+	dupCount     int
+	unknownCount int
+	// guests are unknown call signs that look valid.
+	guests map[string]struct{}
 }
 
 func (c *CheckinCounter) visitDup(d *DupCheckin) {
 	fmt.Printf("%v = \n", d.s)
+	c.dupCount++
+	c.countTraffic(d.record)
 }
 
 func (c *CheckinCounter) visitMember(m *MemberCheckin) {
-	fmt.Printf("%v\n", m.s)
+	if m.record.Callsign != m.s {
+		fmt.Printf("%v (logged as %v)\n", m.s, m.record.Callsign)
+	} else {
+		fmt.Printf("%v\n", m.s)
+	}
 	c.sectionCount++
 	c.totalCount++
+	c.countTraffic(m.record)
+}
+
+func (c *CheckinCounter) countTraffic(r CheckinRecord) {
+	if r.Traffic() {
+		c.trafficCount++
+	}
 }
 
 func (c *CheckinCounter) visitSection() {
@@ -720,11 +1267,25 @@ func (c *CheckinCounter) visitSection() {
 }
 
 func (c *CheckinCounter) visitUnknown(u *UnknownCheckin) {
-	fmt.Printf("%v - \n", u.s)
+	if len(u.suggestions) > 0 {
+		fmt.Printf("%v - did you mean %v?\n", u.s, strings.Join(u.suggestions, " or "))
+	} else if validCallsign(u.s) {
+		fmt.Printf("%v - guest\n", u.s)
+		if c.guests == nil {
+			c.guests = make(map[string]struct{})
+		}
+		c.guests[normalizeCallsign(u.s)] = struct{}{}
+	} else {
+		fmt.Printf("%v - \n", u.s)
+	}
+	c.unknownCount++
+	c.countTraffic(u.record)
 }
 
-func countCheckins(callSigns map[string]Member, netLog <-chan string) {
-	checkinChan := annotateCheckins(callSigns, netLog)
+func countCheckins(callSigns map[string]Member, netLog <-chan CheckinRecord, acceptSuggestions bool) {
+	annotator := newCheckinAnnotator(callSigns)
+	annotator.acceptSuggestions = acceptSuggestions
+	checkinChan := annotator.annotateAll(netLog)
 
 	cc := &CheckinCounter{}
 	for {
@@ -736,13 +1297,20 @@ func countCheckins(callSigns map[string]Member, netLog <-chan string) {
 	}
 
 	fmt.Printf("Confirmed members: %v\n", cc.totalCount)
+	if len(cc.guests) > 0 {
+		fmt.Printf("Guests: %v\n", len(cc.guests))
+	}
+	if cc.trafficCount > 0 {
+		fmt.Printf("Traffic: %v\n", cc.trafficCount)
+	}
 }
 
-func sortCheckins(callSigns map[string]Member, netLog <-chan string) {
+func sortCheckins(callSigns map[string]Member, netLog <-chan CheckinRecord) {
 	confirmedMembers := make(map[string]struct{})
-	for v := range netLog {
-		if _, ok := callSigns[v]; ok {
-			confirmedMembers[v] = struct{}{}
+	for record := range netLog {
+		v := record.Callsign
+		if member, ok := lookupMember(callSigns, v); ok {
+			confirmedMembers[member.Callsign] = struct{}{}
 		}
 	}
 	ls := make([]string, 0, len(confirmedMembers))
@@ -779,8 +1347,8 @@ func validMonthPrefixFormat(monthPrefix *string) bool {
 	return true
 }
 
-func drawTimeSheet(monthPrefix string, logDirectory string, callSigns map[string]Member) error {
-	s, hours, err := drawTimeSheetString(monthPrefix, logDirectory, callSigns)
+func drawTimeSheet(monthPrefix string, logDirectory string, callSigns map[string]Member, policy HoursPolicy) error {
+	s, hours, err := drawTimeSheetString(monthPrefix, logDirectory, callSigns, policy)
 	if err != nil {
 		return err
 	}
@@ -789,32 +1357,64 @@ func drawTimeSheet(monthPrefix string, logDirectory string, callSigns map[string
 	return nil
 }
 
-func drawTimeSheetString(monthPrefix string, logDirectory string, callSigns map[string]Member) (string, float64, error) {
-	var sb strings.Builder
+// TimeSheetRow is the time sheet line of one net log. Time is the time of the
+// net alone and Hours include preparation and reporting time. Method tells how
+// the hours were computed.
+type TimeSheetRow struct {
+	FileName  string
+	Checkins  int
+	Time      float64
+	Hours     float64
+	Prep      float64
+	Reporting float64
+	Method    string
+}
+
+func timeSheetRows(monthPrefix string, logDirectory string, callSigns map[string]Member, policy HoursPolicy) ([]TimeSheetRow, float64, error) {
 	list, err := filepath.Glob(filepath.Join(logDirectory, monthPrefix) + "*")
 	if err != nil {
-		return "", 0, err
+		return nil, 0, err
 	}
+	rows := make([]TimeSheetRow, 0, len(list))
 	var totalHours float64
 	for _, f := range list {
-		checkins, err := readCheckins(f)
+		records, err := readCheckinRecords(f)
 		if err != nil {
-			return "", 0, err
+			return nil, 0, err
 		}
-		totalCount := totalCheckins(callSigns, checkins)
-		hours := float64(totalCount)/3 + 0.5 + 0.25
-		fmt.Fprintf(&sb, "%v:\t%d\t%0.3f\t%0.3f\t%0.3f\t%0.3f\n", filepath.Base(f), totalCount, hours, 0.5, 0.25, hours)
+		totalCount := totalCheckins(callSigns, recordChan(records))
+		netTime, method := netLogTime(records, totalCount, policy)
+		hours, _ := netLogHours(records, totalCount, policy)
+		rows = append(rows, TimeSheetRow{filepath.Base(f), totalCount, netTime, hours, policy.Prep, policy.Reporting, method})
 		totalHours += hours
 	}
+	return rows, totalHours, nil
+}
+
+func drawTimeSheetString(monthPrefix string, logDirectory string, callSigns map[string]Member, policy HoursPolicy) (string, float64, error) {
+	rows, totalHours, err := timeSheetRows(monthPrefix, logDirectory, callSigns, policy)
+	if err != nil {
+		return "", 0, err
+	}
+	return formatTimeSheet(rows, totalHours), totalHours, nil
+}
+
+func formatTimeSheet(rows []TimeSheetRow, totalHours float64) string {
+	var sb strings.Builder
+	for _, r := range rows {
+		fmt.Fprintf(&sb, "%v:\t%d\t%0.3f\t%0.3f\t%0.3f\t%0.3f\t%v\n", r.FileName, r.Checkins, r.Time, r.Prep, r.Reporting, r.Hours, r.Method)
+	}
 	fmt.Fprintf(&sb, "Total hours: %0.3f\n", totalHours)
-	return sb.String(), totalHours, nil
+	return sb.String()
 }
 
-func hospitalHoursCount(monthPrefix string, logDirectory string, callSigns map[string]Member) (float64, error) {
+// hospitalHoursCount returns hours of the hospital net log of the month.
+func hospitalHoursCount(monthPrefix string, logDirectory string, callSigns map[string]Member, policy HoursPolicy) (float64, string, error) {
 	var totalHours float64
+	method := EstimateMethod
 	list, err := filepath.Glob(filepath.Join(logDirectory, monthPrefix) + "*")
 	if err != nil {
-		return 0, err
+		return 0, method, err
 	}
 	log.Tracef("Doing hospital count")
 	for i, f := range list {
@@ -823,14 +1423,13 @@ func hospitalHoursCount(monthPrefix string, logDirectory string, callSigns map[s
 			log.Errorf("More than one hospital net log")
 			break
 		}
-		checkins, err := readCheckins(f)
+		records, err := readCheckinRecords(f)
 		if err != nil {
-			return 0, err
+			return 0, method, err
 		}
-		totalCount := totalCheckins(callSigns, checkins)
-		totalHours = float64(totalCount)*0.5 + 0.25
+		totalHours, _, method = hospitalLogHours(records, policy)
 	}
-	return totalHours, nil
+	return totalHours, method, nil
 }
 
 type TotalCounter struct {
@@ -850,7 +1449,7 @@ func (c *TotalCounter) visitSection() {
 func (c *TotalCounter) visitUnknown(u *UnknownCheckin) {
 }
 
-func totalCheckins(callSigns map[string]Member, netLog <-chan string) (r int) {
+func totalCheckins(callSigns map[string]Member, netLog <-chan CheckinRecord) int {
 	checkinChan := annotateCheckins(callSigns, netLog)
 	tc := &TotalCounter{}
 	for {
@@ -860,5 +1459,5 @@ func totalCheckins(callSigns map[string]Member, netLog <-chan string) (r int) {
 		}
 		c.accept(tc)
 	}
-	return r
+	return tc.totalCount
 }
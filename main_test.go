@@ -1,7 +1,6 @@
 package main
 
 import (
-	"fmt"
 	"testing"
 	"time"
 
@@ -41,3 +40,49 @@ func TestReadHospitalAssignments(t *testing.T) {
 	assert.Equal(t, 1, len(res))
 	assert.Equal(t, "Herman", res["GSH"].Name)
 }
+
+func TestCurrentTimeAsOf(t *testing.T) {
+	now, err := currentTime("2022-09-01")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2022, 9, 1, 0, 0, 0, 0, time.Local), now)
+
+	_, err = currentTime("09/01/2022")
+	assert.NotNil(t, err)
+}
+
+func TestTimeToSendNetSignups(t *testing.T) {
+	tss, nextMonthStart := timeToSendNetSignups(time.Date(2022, 9, 22, 0, 0, 0, 0, time.Local), 10)
+	assert.True(t, tss)
+	assert.Equal(t, time.Date(2022, 10, 1, 0, 0, 0, 0, time.Local), nextMonthStart)
+
+	tss, _ = timeToSendNetSignups(time.Date(2022, 9, 21, 0, 0, 0, 0, time.Local), 10)
+	assert.False(t, tss)
+}
+
+func TestUpcomingWednesdayFourth(t *testing.T) {
+	assert.Equal(t, 4, weekdayNumber(upcomingWeekday(time.Date(2022, 9, 21, 0, 0, 0, 0, time.Local), time.Wednesday)))
+	assert.Equal(t, 3, weekdayNumber(upcomingWeekday(time.Date(2022, 9, 20, 0, 0, 0, 0, time.Local), time.Wednesday)))
+}
+
+func TestNotifyNetControlAsOf(t *testing.T) {
+	callsigns := map[string]Member{
+		"K4LXF4": Member{"Herman", "K4LXF4", "herman@munster.com"},
+		"KJ6ABC": Member{"Lily", "KJ6ABC", "lily@munster.com"},
+	}
+	schedule := []NetcontrolScheduleRecord{
+		{Date: time.Date(2022, 10, 11, 0, 0, 0, 0, time.Local), Callsign: "KJ6ABC"},
+		{Date: time.Date(2022, 10, 4, 0, 0, 0, 0, time.Local), Callsign: "K4LXF4"},
+	}
+	mailer := &RecordingMailer{}
+
+	err := notifyNetControl(time.Date(2022, 10, 9, 0, 0, 0, 0, time.Local), callsigns, &Config{}, mailer, schedule, "")
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"lily@munster.com"}, mailer.Messages[0].GetHeader("To"))
+}
+
+func TestLateNote(t *testing.T) {
+	due := time.Date(2022, 10, 1, 0, 0, 0, 0, time.Local)
+	assert.Equal(t, "", lateNote(time.Date(2022, 10, 1, 8, 0, 0, 0, time.Local), due))
+	assert.Equal(t, "Sorry, this email is late. It was due on 10/1/2022.\n\n", lateNote(time.Date(2022, 10, 3, 8, 0, 0, 0, time.Local), due))
+}
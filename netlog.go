@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CheckinRecord is a line of the net log. Legacy net logs have one call sign
+// per line. Structured net logs have lines in the format
+//
+//	[HH:MM] CALLSIGN [location] [; comment]
+//
+// A comment that starts with "traffic" is traffic passed during the net. A
+// line with only a time marks the time the net was opened or closed. Empty
+// lines separate sections in both formats.
+type CheckinRecord struct {
+	// Time is the time of day of the checkin. It's set only if Timed.
+	Time     time.Duration
+	Timed    bool
+	Callsign string
+	Location string
+	Comment  string
+}
+
+// Section reports whether the record is a section marker.
+func (r CheckinRecord) Section() bool {
+	return r.Callsign == "" && !r.Timed
+}
+
+// Traffic reports whether the record carries traffic.
+func (r CheckinRecord) Traffic() bool {
+	return strings.HasPrefix(strings.ToLower(r.Comment), "traffic")
+}
+
+func (r CheckinRecord) String() string {
+	var sb strings.Builder
+	if r.Timed {
+		fmt.Fprintf(&sb, "%02d:%02d ", int(r.Time.Hours()), int(r.Time.Minutes())%60)
+	}
+	sb.WriteString(r.Callsign)
+	if r.Location != "" {
+		sb.WriteString(" " + r.Location)
+	}
+	if r.Comment != "" {
+		sb.WriteString("; " + r.Comment)
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// parseCheckinLine parses a line of a legacy or structured net log.
+func parseCheckinLine(line string) CheckinRecord {
+	var r CheckinRecord
+	line = strings.TrimSpace(line)
+	if i := strings.Index(line, ";"); i >= 0 {
+		r.Comment = strings.TrimSpace(line[i+1:])
+		line = strings.TrimSpace(line[:i])
+	}
+	fields := strings.Fields(line)
+	if len(fields) > 0 && strings.Contains(fields[0], ":") {
+		if t, err := parseTimeOfDay(fields[0]); err == nil {
+			r.Time = t
+			r.Timed = true
+			fields = fields[1:]
+		}
+	}
+	if len(fields) > 0 {
+		r.Callsign = strings.ToUpper(fields[0])
+		r.Location = strings.Join(fields[1:], " ")
+	}
+	return r
+}
+
+// Methods of computing volunteer hours in the time sheet.
+const (
+	// TimestampsMethod uses the time between the first and the last
+	// timestamps of the net log.
+	TimestampsMethod = "timestamps"
+	// EstimateMethod estimates the time from the number of checkins.
+	EstimateMethod = "estimate"
+)
+
+func readCheckinRecords(netLog string) ([]CheckinRecord, error) {
+	checkins, err := readCheckins(netLog)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]CheckinRecord, 0)
+	for r := range checkins {
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+func recordChan(records []CheckinRecord) <-chan CheckinRecord {
+	r := make(chan CheckinRecord)
+	go func() {
+		for _, record := range records {
+			r <- record
+		}
+		close(r)
+	}()
+	return r
+}
+
+// netSpan returns the times of the first and the last timed records of the
+// net log. Times that go back more than 12 hours are considered to be past
+// midnight. The span is only known if there are at least two timestamps.
+func netSpan(records []CheckinRecord) (start, end time.Duration, ok bool) {
+	var day time.Duration
+	count := 0
+	for _, r := range records {
+		if !r.Timed {
+			continue
+		}
+		t := r.Time + day
+		if count > 0 && t < end-12*time.Hour {
+			day += 24 * time.Hour
+			t += 24 * time.Hour
+		}
+		if count == 0 {
+			start = t
+		}
+		end = t
+		count++
+	}
+	return start, end, count > 1 && end > start
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCheckinLineLegacy(t *testing.T) {
+	assert.Equal(t, CheckinRecord{Callsign: "K4LXF4"}, parseCheckinLine(" k4lxf4 "))
+	assert.True(t, parseCheckinLine("").Section())
+}
+
+func TestParseCheckinLineStructured(t *testing.T) {
+	r := parseCheckinLine("19:32 kj6abc San Jose; traffic for OCH")
+	assert.Equal(t, CheckinRecord{
+		Time:     19*time.Hour + 32*time.Minute,
+		Timed:    true,
+		Callsign: "KJ6ABC",
+		Location: "San Jose",
+		Comment:  "traffic for OCH",
+	}, r)
+	assert.True(t, r.Traffic())
+	assert.Equal(t, "19:32 KJ6ABC San Jose; traffic for OCH", r.String())
+
+	mark := parseCheckinLine("20:05 ; net closed")
+	assert.True(t, mark.Timed)
+	assert.False(t, mark.Section())
+	assert.Equal(t, "", mark.Callsign)
+	assert.False(t, mark.Traffic())
+}
+
+func TestAnnotateStructuredLog(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "2022-10-04.txt")
+	err := ioutil.WriteFile(fileName, []byte("19:30\nN6DVS\n19:31 K4LXF4 Campbell\n\n19:40 K4LXF4; traffic for GSH\n19:41 XX1XX\n20:00\n"), 0644)
+	assert.Nil(t, err)
+	callsigns := map[string]Member{
+		"N6DVS":  Member{"Victor", "N6DVS", ""},
+		"K4LXF4": Member{"Herman", "K4LXF4", ""},
+	}
+	checkins, err := readCheckins(fileName)
+	assert.Nil(t, err)
+
+	var kinds []string
+	for item := range annotateCheckins(callsigns, checkins) {
+		switch c := item.(type) {
+		case *MemberCheckin:
+			kinds = append(kinds, "member "+c.s)
+		case *DupCheckin:
+			kinds = append(kinds, "dup "+c.record.Comment)
+		case *UnknownCheckin:
+			kinds = append(kinds, "unknown "+c.s)
+		case *SectionCheckin:
+			kinds = append(kinds, "section")
+		}
+	}
+	assert.Equal(t, []string{"member N6DVS", "member K4LXF4", "section", "dup traffic for GSH", "unknown XX1XX", "section"}, kinds)
+}
+
+func TestNetSpan(t *testing.T) {
+	_, _, ok := netSpan([]CheckinRecord{{Callsign: "K4LXF4"}, {Callsign: "N6DVS"}})
+	assert.False(t, ok)
+
+	start, end, ok := netSpan([]CheckinRecord{
+		parseCheckinLine("23:50"),
+		parseCheckinLine("K4LXF4"),
+		parseCheckinLine("00:20 N6DVS"),
+	})
+	assert.True(t, ok)
+	assert.Equal(t, 30*time.Minute, end-start)
+}
+
+func TestTimeSheetMethods(t *testing.T) {
+	dir := t.TempDir()
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "2022-10-04.txt"), []byte("19:30\nN6DVS\nK4LXF4\n20:30\n"), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "2022-10-11.txt"), []byte("N6DVS\nK4LXF4\nXX1XX\n"), 0644))
+	callsigns := map[string]Member{
+		"N6DVS":  Member{"Victor", "N6DVS", ""},
+		"K4LXF4": Member{"Herman", "K4LXF4", ""},
+	}
+	rows, total, err := timeSheetRows("2022-10", dir, callsigns, DefaultNetHours)
+	assert.Nil(t, err)
+	assert.Equal(t, []TimeSheetRow{
+		{"2022-10-04.txt", 2, 1, 1.75, 0.5, 0.25, TimestampsMethod},
+		{"2022-10-11.txt", 2, float64(2) / 3, float64(2)/3 + 0.5 + 0.25, 0.5, 0.25, EstimateMethod},
+	}, rows)
+	assert.InDelta(t, 1.75+2.0/3+0.75, total, 0.0001)
+}
+
+func TestHospitalHoursCount(t *testing.T) {
+	dir := t.TempDir()
+	fileName := filepath.Join(dir, "2022-10-26.txt")
+	assert.Nil(t, ioutil.WriteFile(fileName, []byte("GSH K4LXF4\nOCH N6DVS\n"), 0644))
+	hours, method, err := hospitalHoursCount("2022-10", dir, nil, DefaultHospitalHours)
+	assert.Nil(t, err)
+	assert.Equal(t, EstimateMethod, method)
+	assert.Equal(t, 1.25, hours)
+
+	assert.Nil(t, ioutil.WriteFile(fileName, []byte("19:00\n19:00 GSH K4LXF4\n19:30 OCH N6DVS\n20:00\n"), 0644))
+	hours, method, err = hospitalHoursCount("2022-10", dir, nil, DefaultHospitalHours)
+	assert.Nil(t, err)
+	assert.Equal(t, TimestampsMethod, method)
+	assert.Equal(t, 1.75, hours)
+
+	assignments, err := readHospitalLog(fileName, testSignupCallsigns())
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(assignments))
+}
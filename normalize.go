@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// callsignSuffixes are suffixes of portable and mobile stations.
+var callsignSuffixes = []string{"/M", "/P", "/MM", "/AM", "/QRP"}
+
+// ssidPattern matches SSID suffixes like -5.
+var ssidPattern = regexp.MustCompile(`-[0-9]{1,2}$`)
+
+// trimCallsignSuffix removes a portable or mobile suffix from the call sign.
+func trimCallsignSuffix(callsign string) string {
+	for _, s := range callsignSuffixes {
+		if strings.HasSuffix(callsign, s) {
+			return strings.TrimSuffix(callsign, s)
+		}
+	}
+	return callsign
+}
+
+// normalizeCallsign returns the upper case call sign without portable,
+// mobile and SSID suffixes, e.g. N6DVS for n6dvs/m or W6XRL-5.
+func normalizeCallsign(callsign string) string {
+	c := strings.ToUpper(strings.TrimSpace(callsign))
+	c = ssidPattern.ReplaceAllString(c, "")
+	return trimCallsignSuffix(c)
+}
+
+// normalizeTactical returns the tactical call sign in upper case with single
+// spaces between words.
+func normalizeTactical(name string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(name), " "))
+}
+
+// lookupMember finds the member of the call sign as it was logged. Tactical
+// call signs are found too because they are in the call sign database.
+func lookupMember(callSigns map[string]Member, callsign string) (Member, bool) {
+	if m, ok := callSigns[normalizeTactical(callsign)]; ok {
+		return m, true
+	}
+	m, ok := callSigns[normalizeCallsign(callsign)]
+	return m, ok
+}
+
+// addTacticalCallsigns adds tactical call signs to the call sign database as
+// aliases of their operators.
+func addTacticalCallsigns(callSigns map[string]Member, tactical map[string]string) error {
+	for name, operator := range tactical {
+		m, ok := lookupMember(callSigns, operator)
+		if !ok {
+			return fmt.Errorf("Unknown operator %v of tactical call sign %v", operator, name)
+		}
+		callSigns[normalizeTactical(name)] = m
+	}
+	return nil
+}
+
+// loadCallsignDB reads the call sign database with tactical call signs of the
+// configuration.
+func loadCallsignDB(config *Config) (map[string]Member, error) {
+	callSigns, err := readCallsignDB()
+	if err != nil {
+		return nil, err
+	}
+	if config != nil {
+		err = addTacticalCallsigns(callSigns, config.TacticalCallsigns)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return callSigns, nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeCallsign(t *testing.T) {
+	assert.Equal(t, "N6DVS", normalizeCallsign(" n6dvs/m "))
+	assert.Equal(t, "N6DVS", normalizeCallsign("N6DVS/P"))
+	assert.Equal(t, "W6XRL", normalizeCallsign("W6XRL-5"))
+	assert.Equal(t, "KJ6ABC", normalizeCallsign("KJ6ABC"))
+}
+
+func TestTacticalCallsigns(t *testing.T) {
+	callsigns := testSignupCallsigns()
+	err := addTacticalCallsigns(callsigns, map[string]string{"gsh  net": "K4LXF4", "OCH": "kj6abc"})
+	assert.Nil(t, err)
+	m, ok := lookupMember(callsigns, "GSH NET")
+	assert.True(t, ok)
+	assert.Equal(t, "K4LXF4", m.Callsign)
+
+	err = addTacticalCallsigns(callsigns, map[string]string{"VMC": "W1XYZ"})
+	assert.NotNil(t, err)
+
+	annotator := newCheckinAnnotator(callsigns)
+	var items []CheckinItem
+	for _, l := range []string{"19:30 gsh net", "K4LXF4/M", "OCH", "W6XRL-5"} {
+		items = append(items, annotator.annotate(parseCheckinLine(l)))
+	}
+	assert.Equal(t, "K4LXF4", items[0].(*MemberCheckin).s)
+	assert.Equal(t, "K4LXF4", items[1].(*DupCheckin).s)
+	assert.Equal(t, "KJ6ABC", items[2].(*MemberCheckin).s)
+	assert.Equal(t, "W6XRL-5", items[3].(*UnknownCheckin).s)
+}
+
+func TestReadHospitalLogSuffixes(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "2022-10-26.txt")
+	assert.Nil(t, ioutil.WriteFile(fileName, []byte("GSH K4LXF4/P\nOCH N6DVS-7\n"), 0644))
+	assignments, err := readHospitalLog(fileName, testSignupCallsigns())
+	assert.Nil(t, err)
+	assert.Equal(t, "K4LXF4", assignments[0].Member.Callsign)
+	assert.Equal(t, "N6DVS", assignments[1].Member.Callsign)
+}
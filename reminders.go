@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/gomail.v2"
+)
+
+var reminderStageNamePattern = regexp.MustCompile(`^[a-z0-9-]+$`)
+
+// ReminderStage reminds net controls about their net Days days before it.
+// Every stage is rendered from net-control-reminder-<Name>.tmpl template and
+// recorded in the ledger separately.
+type ReminderStage struct {
+	Name string `yaml:"name"`
+	Days int    `yaml:"days"`
+}
+
+// kind is the ledger kind and the template name of the stage.
+func (s ReminderStage) kind() string {
+	return NetControlReminderKind + "-" + s.Name
+}
+
+func validateReminderStages(stages []ReminderStage) error {
+	names := make(map[string]struct{})
+	for _, s := range stages {
+		if !reminderStageNamePattern.MatchString(s.Name) {
+			return fmt.Errorf("Reminder name should consist of lower case letters, digits and dashes: %v", s.Name)
+		}
+		if _, ok := names[s.Name]; ok {
+			return fmt.Errorf("Duplicate reminder: %v", s.Name)
+		}
+		names[s.Name] = struct{}{}
+		if s.Days < 0 {
+			return fmt.Errorf("Number of days before net should not be negative: %v", s.Days)
+		}
+	}
+	return nil
+}
+
+// dueReminderStage returns the stage that should be sent when the net is
+// daysLeft days ahead. It's the latest stage that has started, so stages
+// missed earlier are skipped instead of being sent all at once.
+func dueReminderStage(stages []ReminderStage, daysLeft int) (stage ReminderStage, ok bool) {
+	for _, s := range stages {
+		if s.Days >= daysLeft && (!ok || s.Days < stage.Days) {
+			stage, ok = s, true
+		}
+	}
+	return
+}
+
+// sendReminders sends the due reminder stage for every upcoming net. Every
+// stage is sent once per net unless force is set.
+func sendReminders(now time.Time, callsignDB map[string]Member, config *Config, mailer Mailer, ledger *Ledger, ncSchedule []NetcontrolScheduleRecord, force bool) error {
+	if config == nil || len(config.Reminders) == 0 {
+		return nil
+	}
+	err := validateReminderStages(config.Reminders)
+	if err != nil {
+		return fmt.Errorf("Invalid reminder: %w", err)
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	for _, record := range ncSchedule {
+		netDate := time.Date(record.Date.Year(), record.Date.Month(), record.Date.Day(), 0, 0, 0, 0, now.Location())
+		if netDate.Before(today) || record.Confirmation == ConfirmationDeclined {
+			continue
+		}
+		daysLeft := int(netDate.Sub(today).Hours()/24 + 0.5)
+		stage, ok := dueReminderStage(config.Reminders, daysLeft)
+		if !ok {
+			continue
+		}
+		period := netDateString(netDate)
+		if !shouldSend(ledger, stage.kind(), period, force) {
+			continue
+		}
+		err = sendReminder(record, stage, daysLeft, callsignDB, config, &LedgerMailer{mailer, ledger, stage.kind(), period})
+		if err != nil {
+			fmt.Printf("Failed to send %v for %v: %v\n", stage.kind(), period, err)
+		}
+	}
+	return nil
+}
+
+func sendReminder(record NetcontrolScheduleRecord, stage ReminderStage, daysLeft int, callsignDB map[string]Member, config *Config, mailer Mailer) error {
+	ncCallsign := strings.ToUpper(record.Callsign)
+	member := callsignDB[ncCallsign]
+	if member.Email == "" {
+		return fmt.Errorf("Net control %v has empty email", ncCallsign)
+	}
+	m := gomail.NewMessage()
+	m.SetHeader("From", config.Station.Mail.Email)
+	m.SetHeader("To", member.Email)
+	alternate := callsignDB[strings.ToUpper(record.Alternate)]
+	if alternate.Email != "" {
+		m.SetHeader("Cc", alternate.Email)
+	}
+	m.SetHeader("Bcc", config.Station.Mail.Email)
+	m.SetHeader("Subject", fmt.Sprintf("Net control reminder %v", record.Date.Format("1/2/2006")))
+	err := setBody(m, stage.kind(), MessageData{
+		Station:   config.Station,
+		Month:     time.Date(record.Date.Year(), record.Date.Month(), 1, 0, 0, 0, 0, record.Date.Location()),
+		Member:    member,
+		Alternate: alternate,
+		NetDate:   record.Date,
+		DaysLeft:  daysLeft,
+	})
+	if err != nil {
+		return err
+	}
+	if err := mailer.Send(m); err != nil {
+		return fmt.Errorf("Failed to send email: %w", err)
+	}
+	return nil
+}
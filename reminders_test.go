@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testReminderStages = []ReminderStage{
+	{Name: "two-weeks", Days: 14},
+	{Name: "week", Days: 7},
+	{Name: "day-of", Days: 0},
+}
+
+func TestDueReminderStage(t *testing.T) {
+	_, ok := dueReminderStage(testReminderStages, 20)
+	assert.False(t, ok)
+	stage, ok := dueReminderStage(testReminderStages, 14)
+	assert.True(t, ok)
+	assert.Equal(t, "two-weeks", stage.Name)
+	stage, _ = dueReminderStage(testReminderStages, 5)
+	assert.Equal(t, "week", stage.Name)
+	stage, _ = dueReminderStage(testReminderStages, 0)
+	assert.Equal(t, "day-of", stage.Name)
+}
+
+func TestValidateReminderStages(t *testing.T) {
+	assert.Nil(t, validateReminderStages(testReminderStages))
+	assert.NotNil(t, validateReminderStages([]ReminderStage{{Name: "Two Weeks", Days: 14}}))
+	assert.NotNil(t, validateReminderStages([]ReminderStage{{Name: "week", Days: 7}, {Name: "week", Days: 6}}))
+	assert.NotNil(t, validateReminderStages([]ReminderStage{{Name: "late", Days: -1}}))
+}
+
+func TestSendRemindersOncePerStage(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	configDir := filepath.Join(home, ".net-manager")
+	assert.Nil(t, os.MkdirAll(configDir, 0755))
+	err := ioutil.WriteFile(filepath.Join(configDir, "net-control-reminder-day-of.tmpl"), []byte("Good luck today, {{.Member.Callsign}}!"), 0644)
+	assert.Nil(t, err)
+	callsigns := testSignupCallsigns()
+	config := &Config{Reminders: testReminderStages}
+	netDate := time.Date(2022, 10, 25, 0, 0, 0, 0, time.Local)
+	schedule := []NetcontrolScheduleRecord{
+		{Date: netDate, Callsign: "K4LXF4", Confirmation: ConfirmationPending},
+		{Date: netDate.AddDate(0, 0, -7), Callsign: "N6DVS", Confirmation: ConfirmationDeclined},
+	}
+	mailer := &RecordingMailer{}
+	ledger := &Ledger{DryRun: true}
+
+	for _, day := range []int{10, 11, 15, 16, 18, 25} {
+		now := time.Date(2022, 10, day, 8, 0, 0, 0, time.Local)
+		assert.Nil(t, sendReminders(now, callsigns, config, mailer, ledger, schedule, false))
+	}
+	assert.Equal(t, 3, len(mailer.Messages))
+	assert.True(t, ledger.Sent("net-control-reminder-two-weeks", "2022-10-25"))
+	assert.True(t, ledger.Sent("net-control-reminder-week", "2022-10-25"))
+	assert.True(t, ledger.Sent("net-control-reminder-day-of", "2022-10-25"))
+
+	var buf bytes.Buffer
+	assert.Nil(t, writeMessage(&buf, mailer.Messages[1]))
+	assert.Contains(t, buf.String(), "running the net in 7 days on 10/25/2022")
+	buf.Reset()
+	assert.Nil(t, writeMessage(&buf, mailer.Messages[2]))
+	assert.Contains(t, buf.String(), "Good luck today, K4LXF4!")
+
+	now := time.Date(2022, 10, 25, 8, 0, 0, 0, time.Local)
+	assert.Nil(t, sendReminders(now, callsigns, config, mailer, ledger, schedule, true))
+	assert.Equal(t, 4, len(mailer.Messages))
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Triggers of dispatch rules.
+const (
+	// WeekdayTrigger fires on every Weekday.
+	WeekdayTrigger = "weekday"
+	// MonthDayTrigger fires on day Offset of every month.
+	MonthDayTrigger = "month-day"
+	// BeforeMonthTrigger fires every day when the next month starts in less
+	// than Offset days.
+	BeforeMonthTrigger = "before-month"
+	// BeforeWeekdayOfMonthTrigger fires every day when the Week-th Weekday of
+	// the month is at most Offset days ahead.
+	BeforeWeekdayOfMonthTrigger = "before-weekday-of-month"
+	// BeforeWeekdayTrigger fires every day when the next Weekday is at most
+	// Offset days ahead.
+	BeforeWeekdayTrigger = "before-weekday"
+	// BeforeNetTrigger fires every day for every net of the net control
+	// schedule that is at most Offset days ahead.
+	BeforeNetTrigger = "before-net"
+)
+
+// Rule tells dispatchEmails when to send a message. Message is one of the
+// ledger kinds.
+type Rule struct {
+	Message string `yaml:"message"`
+	Trigger string `yaml:"trigger"`
+	Weekday string `yaml:"weekday"`
+	Offset  int    `yaml:"offset"`
+	Week    int    `yaml:"week"`
+}
+
+var defaultRules = []Rule{
+	{Message: NetSignupsKind, Trigger: BeforeMonthTrigger, Offset: 10},
+	{Message: NetControlAlertKind, Trigger: WeekdayTrigger, Weekday: "sunday"},
+	{Message: ReportKind, Trigger: MonthDayTrigger, Offset: 1},
+	{Message: HospitalSignupsKind, Trigger: BeforeWeekdayOfMonthTrigger, Weekday: "wednesday", Week: 4, Offset: 7},
+}
+
+func dispatchRules(config *Config) []Rule {
+	if config == nil || len(config.Rules) == 0 {
+		return defaultRules
+	}
+	return config.Rules
+}
+
+func parseWeekday(s string) (time.Weekday, error) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(d.String(), s) {
+			return d, nil
+		}
+	}
+	return time.Sunday, fmt.Errorf("Unknown weekday: %v", s)
+}
+
+func (r Rule) validate() error {
+	switch r.Message {
+	case NetSignupsKind, NetControlAlertKind, ReportKind, HospitalSignupsKind, HospitalAlertKind, NetControlEscalationKind, SubstituteRequestKind:
+	default:
+		return fmt.Errorf("Unknown message in rule: %v", r.Message)
+	}
+	switch r.Trigger {
+	case WeekdayTrigger:
+		_, err := parseWeekday(r.Weekday)
+		return err
+	case MonthDayTrigger:
+		if r.Offset < 1 || r.Offset > 28 {
+			return fmt.Errorf("Day of month should be between 1 and 28: %v", r.Offset)
+		}
+	case BeforeMonthTrigger:
+		if r.Offset < 1 {
+			return fmt.Errorf("Number of days before month should be positive: %v", r.Offset)
+		}
+	case BeforeWeekdayOfMonthTrigger:
+		_, err := parseWeekday(r.Weekday)
+		if err != nil {
+			return err
+		}
+		if r.Week < 1 || r.Week > 5 {
+			return fmt.Errorf("Week of month should be between 1 and 5: %v", r.Week)
+		}
+		if r.Offset < 1 {
+			return fmt.Errorf("Number of days before weekday should be positive: %v", r.Offset)
+		}
+	case BeforeWeekdayTrigger:
+		_, err := parseWeekday(r.Weekday)
+		if err != nil {
+			return err
+		}
+		if r.Offset < 1 {
+			return fmt.Errorf("Number of days before weekday should be positive: %v", r.Offset)
+		}
+	case BeforeNetTrigger:
+		if r.Offset < 0 {
+			return fmt.Errorf("Number of days before net should not be negative: %v", r.Offset)
+		}
+	default:
+		return fmt.Errorf("Unknown trigger in rule: %v", r.Trigger)
+	}
+	return nil
+}
+
+// mandatory messages are caught up when they are missed.
+func (r Rule) mandatory() bool {
+	return r.Message == ReportKind || r.Message == NetControlAlertKind
+}
+
+// RuleEvent is a firing of a rule. Target is the date the message is about:
+// the first day of the month for signups, the date of the weekday or the net
+// for hospital signups and escalations, the due date for reports and alerts.
+type RuleEvent struct {
+	Due    time.Time
+	Target time.Time
+}
+
+// events returns firings of the rule that should be handled now. For
+// mandatory messages the firings missed since the ledger started tracking are
+// returned too, but only the most recent one for alerts. The rule must be
+// valid.
+func (r Rule) events(now time.Time, ledger *Ledger, ncSchedule []NetcontrolScheduleRecord) (events []RuleEvent) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch r.Trigger {
+	case BeforeMonthTrigger:
+		if fire, nextMonthStart := timeToSendNetSignups(now, r.Offset); fire {
+			events = append(events, RuleEvent{today, nextMonthStart})
+		}
+		return
+	case BeforeWeekdayOfMonthTrigger:
+		weekday, _ := parseWeekday(r.Weekday)
+		target := upcomingWeekday(now, weekday)
+		if weekdayNumber(target) == r.Week && target.Sub(today) <= time.Duration(r.Offset)*24*time.Hour {
+			events = append(events, RuleEvent{today, target})
+		}
+		return
+	case BeforeWeekdayTrigger:
+		weekday, _ := parseWeekday(r.Weekday)
+		target := upcomingWeekday(now, weekday)
+		if target.Sub(today) <= time.Duration(r.Offset)*24*time.Hour {
+			events = append(events, RuleEvent{today, target})
+		}
+		return
+	case BeforeNetTrigger:
+		for _, record := range ncSchedule {
+			netDate := time.Date(record.Date.Year(), record.Date.Month(), record.Date.Day(), 0, 0, 0, 0, now.Location())
+			if !netDate.Before(today) && netDate.Sub(today) <= time.Duration(r.Offset)*24*time.Hour {
+				events = append(events, RuleEvent{today, netDate})
+			}
+		}
+		return
+	}
+	due := r.lastOccurrence(today)
+	if equalByDate(due, today) {
+		events = append(events, RuleEvent{due, due})
+		due = r.previousOccurrence(due)
+	}
+	for r.mandatory() && ledger.Tracked(due) {
+		// An alert is about the next net only, so older alerts are not
+		// caught up.
+		if r.Message == NetControlAlertKind && len(events) > 0 {
+			break
+		}
+		events = append([]RuleEvent{{due, due}}, events...)
+		due = r.previousOccurrence(due)
+	}
+	return
+}
+
+// lastOccurrence returns the most recent firing date of a weekday or month
+// day rule not later than t.
+func (r Rule) lastOccurrence(t time.Time) time.Time {
+	if r.Trigger == MonthDayTrigger {
+		if t.Day() >= r.Offset {
+			return time.Date(t.Year(), t.Month(), r.Offset, 0, 0, 0, 0, t.Location())
+		}
+		return time.Date(t.Year(), t.Month()-1, r.Offset, 0, 0, 0, 0, t.Location())
+	}
+	weekday, _ := parseWeekday(r.Weekday)
+	return lastWeekday(t, weekday)
+}
+
+func (r Rule) previousOccurrence(t time.Time) time.Time {
+	if r.Trigger == MonthDayTrigger {
+		return time.Date(t.Year(), t.Month()-1, t.Day(), 0, 0, 0, 0, t.Location())
+	}
+	return time.Date(t.Year(), t.Month(), t.Day()-7, 0, 0, 0, 0, t.Location())
+}
+
+// lastWeekday returns the most recent weekday not later than t.
+func lastWeekday(t time.Time, weekday time.Weekday) time.Time {
+	days := int(t.Weekday() - weekday)
+	if days < 0 {
+		days += 7
+	}
+	return time.Date(t.Year(), t.Month(), t.Day()-days, 0, 0, 0, 0, t.Location())
+}
+
+func upcomingWeekday(t time.Time, weekday time.Weekday) time.Time {
+	days := int(weekday - t.Weekday())
+	if days <= 0 {
+		days += 7
+	}
+	return time.Date(t.Year(), t.Month(), t.Day()+days, 0, 0, 0, 0, t.Location())
+}
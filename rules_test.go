@@ -0,0 +1,103 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	alertRule    = defaultRules[1]
+	reportRule   = defaultRules[2]
+	hospitalRule = defaultRules[3]
+)
+
+func TestDefaultRulesAreValid(t *testing.T) {
+	for _, rule := range defaultRules {
+		assert.Nil(t, rule.validate())
+	}
+}
+
+func TestRuleValidate(t *testing.T) {
+	assert.NotNil(t, Rule{Message: "newsletter", Trigger: WeekdayTrigger, Weekday: "sunday"}.validate())
+	assert.NotNil(t, Rule{Message: ReportKind, Trigger: "hourly"}.validate())
+	assert.NotNil(t, Rule{Message: ReportKind, Trigger: WeekdayTrigger, Weekday: "someday"}.validate())
+	assert.NotNil(t, Rule{Message: ReportKind, Trigger: MonthDayTrigger, Offset: 31}.validate())
+	assert.Nil(t, Rule{Message: ReportKind, Trigger: MonthDayTrigger, Offset: 5}.validate())
+	assert.Nil(t, Rule{Message: HospitalAlertKind, Trigger: WeekdayTrigger, Weekday: "monday"}.validate())
+}
+
+func TestLastWeekday(t *testing.T) {
+	assert.Equal(t, time.Date(2022, 10, 9, 0, 0, 0, 0, time.Local), lastWeekday(time.Date(2022, 10, 11, 8, 0, 0, 0, time.Local), time.Sunday))
+	assert.Equal(t, time.Date(2022, 10, 9, 0, 0, 0, 0, time.Local), lastWeekday(time.Date(2022, 10, 9, 8, 0, 0, 0, time.Local), time.Sunday))
+	assert.Equal(t, time.Date(2022, 10, 4, 0, 0, 0, 0, time.Local), lastWeekday(time.Date(2022, 10, 9, 8, 0, 0, 0, time.Local), time.Tuesday))
+}
+
+func TestAlertRuleFiresOnSunday(t *testing.T) {
+	ledger := &Ledger{DryRun: true}
+	sunday := time.Date(2022, 10, 9, 0, 0, 0, 0, time.Local)
+	assert.Equal(t, []RuleEvent{{sunday, sunday}}, alertRule.events(sunday.Add(8*time.Hour), ledger, nil))
+	assert.Equal(t, 0, len(alertRule.events(time.Date(2022, 10, 10, 8, 0, 0, 0, time.Local), ledger, nil)))
+}
+
+func TestAlertRuleCatchUp(t *testing.T) {
+	ledger := &Ledger{DryRun: true}
+	ledger.Record(NetControlAlertKind, "2022-10-04", time.Date(2022, 10, 2, 8, 0, 0, 0, time.Local))
+	sunday := time.Date(2022, 10, 9, 0, 0, 0, 0, time.Local)
+	assert.Equal(t, []RuleEvent{{sunday, sunday}}, alertRule.events(time.Date(2022, 10, 10, 8, 0, 0, 0, time.Local), ledger, nil))
+
+	// Sundays missed before the last one are about nets that are over.
+	ledger.Record(ReportKind, "2022-07", time.Date(2022, 8, 1, 8, 0, 0, 0, time.Local))
+	assert.Equal(t, []RuleEvent{{sunday, sunday}}, alertRule.events(time.Date(2022, 10, 10, 8, 0, 0, 0, time.Local), ledger, nil))
+	assert.Equal(t, []RuleEvent{{sunday, sunday}}, alertRule.events(sunday.Add(8*time.Hour), ledger, nil))
+}
+
+func TestReportRuleCatchUp(t *testing.T) {
+	ledger := &Ledger{DryRun: true}
+	ledger.Record(ReportKind, "2022-07", time.Date(2022, 8, 1, 8, 0, 0, 0, time.Local))
+
+	events := reportRule.events(time.Date(2022, 10, 3, 8, 0, 0, 0, time.Local), ledger, nil)
+	dues := make([]time.Time, 0)
+	for _, e := range events {
+		dues = append(dues, e.Due)
+	}
+	assert.Equal(t, []time.Time{
+		time.Date(2022, 8, 1, 0, 0, 0, 0, time.Local),
+		time.Date(2022, 9, 1, 0, 0, 0, 0, time.Local),
+		time.Date(2022, 10, 1, 0, 0, 0, 0, time.Local),
+	}, dues)
+}
+
+func TestReportRuleWithoutLedger(t *testing.T) {
+	ledger := &Ledger{DryRun: true}
+	assert.Equal(t, 0, len(reportRule.events(time.Date(2022, 10, 3, 8, 0, 0, 0, time.Local), ledger, nil)))
+	first := time.Date(2022, 10, 1, 0, 0, 0, 0, time.Local)
+	assert.Equal(t, []RuleEvent{{first, first}}, reportRule.events(first.Add(8*time.Hour), ledger, nil))
+}
+
+func TestHospitalRuleWeekBeforeFourthWednesday(t *testing.T) {
+	ledger := &Ledger{DryRun: true}
+	fourthWednesday := time.Date(2022, 9, 28, 0, 0, 0, 0, time.Local)
+	events := hospitalRule.events(time.Date(2022, 9, 21, 8, 0, 0, 0, time.Local), ledger, nil)
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, fourthWednesday, events[0].Target)
+	assert.Equal(t, 0, len(hospitalRule.events(time.Date(2022, 9, 20, 8, 0, 0, 0, time.Local), ledger, nil)))
+}
+
+func TestMessagePeriod(t *testing.T) {
+	schedule := []NetcontrolScheduleRecord{
+		{Date: time.Date(2022, 10, 11, 0, 0, 0, 0, time.Local), Callsign: "KJ6ABC"},
+	}
+	target := time.Date(2022, 10, 9, 0, 0, 0, 0, time.Local)
+	period, err := messagePeriod(NetControlAlertKind, target, schedule)
+	assert.Nil(t, err)
+	assert.Equal(t, "2022-10-11", period)
+
+	period, err = messagePeriod(ReportKind, time.Date(2022, 10, 1, 0, 0, 0, 0, time.Local), schedule)
+	assert.Nil(t, err)
+	assert.Equal(t, "2022-09", period)
+
+	assert.True(t, messageExpired(NetControlAlertKind, "2022-10-11", time.Date(2022, 10, 12, 8, 0, 0, 0, time.Local)))
+	assert.False(t, messageExpired(NetControlAlertKind, "2022-10-11", time.Date(2022, 10, 10, 8, 0, 0, 0, time.Local)))
+}
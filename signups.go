@@ -0,0 +1,468 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Signup is a request to run a net or to operate from a hospital found in a
+// reply to a signup announcement. Hospital is empty for net control signups.
+type Signup struct {
+	Member   Member
+	Date     time.Time
+	Hospital string
+	// Source describes the message the signup was found in.
+	Source string
+}
+
+func (s Signup) String() string {
+	if s.Hospital != "" {
+		return fmt.Sprintf("Hospital net %v %v: %v", s.Date.Format("1/2/2006"), s.Hospital, s.Member.Callsign)
+	}
+	return fmt.Sprintf("Net control %v: %v", s.Date.Format("1/2/2006"), s.Member.Callsign)
+}
+
+var (
+	signupDatePattern     = regexp.MustCompile(`\b(\d{1,2})/(\d{1,2})(?:/(\d{4}|\d{2}))?\b`)
+	signupCallsignPattern = regexp.MustCompile(`^[A-Z0-9]*[A-Z][A-Z0-9]*$`)
+	replyHeaderPattern    = regexp.MustCompile(`^On .* wrote:$`)
+)
+
+// readMailbox reads messages from an mbox file or a Maildir directory. Files
+// in the directory itself are read as single messages too, so a directory
+// of .eml files works as well.
+func readMailbox(path string) ([]*mail.Message, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open mailbox: %w", err)
+	}
+	if !info.IsDir() {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to open mailbox: %w", err)
+		}
+		defer f.Close()
+		return readMbox(f)
+	}
+	var fileNames []string
+	for _, dir := range []string{path, filepath.Join(path, "new"), filepath.Join(path, "cur")} {
+		entries, err := ioutil.ReadDir(dir)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read mailbox: %w", err)
+		}
+		for _, e := range entries {
+			if e.Mode().IsRegular() && !strings.HasPrefix(e.Name(), ".") {
+				fileNames = append(fileNames, filepath.Join(dir, e.Name()))
+			}
+		}
+	}
+	sort.Strings(fileNames)
+	messages := make([]*mail.Message, 0, len(fileNames))
+	for _, fileName := range fileNames {
+		data, err := ioutil.ReadFile(fileName)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read message: %w", err)
+		}
+		msg, err := mail.ReadMessage(bytes.NewReader(data))
+		if err != nil {
+			log.Errorf("Skipping unreadable message %v: %v", fileName, err)
+			continue
+		}
+		messages = append(messages, msg)
+	}
+	return messages, nil
+}
+
+// readMbox splits an mbox file into messages. Lines starting with "From "
+// separate messages, ">From " lines are unescaped.
+func readMbox(r io.Reader) ([]*mail.Message, error) {
+	messages := make([]*mail.Message, 0)
+	var current *bytes.Buffer
+	flush := func() {
+		if current == nil {
+			return
+		}
+		msg, err := mail.ReadMessage(current)
+		if err != nil {
+			log.Errorf("Skipping unreadable message: %v", err)
+		} else {
+			messages = append(messages, msg)
+		}
+	}
+	lineReader := bufio.NewReader(r)
+	previousBlank := true
+	for {
+		line, err := lineReader.ReadString('\n')
+		if line != "" {
+			if previousBlank && strings.HasPrefix(line, "From ") {
+				flush()
+				current = &bytes.Buffer{}
+			} else if current != nil {
+				if strings.HasPrefix(line, ">") && strings.HasPrefix(strings.TrimLeft(line, ">"), "From ") {
+					line = line[1:]
+				}
+				current.WriteString(line)
+			}
+			previousBlank = strings.TrimSpace(line) == ""
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read mbox: %w", err)
+		}
+	}
+	flush()
+	return messages, nil
+}
+
+// messageText returns text/plain parts of the message without the quoted
+// original message.
+func messageText(msg *mail.Message) (string, error) {
+	var sb strings.Builder
+	err := collectText(&sb, textproto.MIMEHeader(msg.Header), msg.Body)
+	if err != nil {
+		return "", err
+	}
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(sb.String(), "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if replyHeaderPattern.MatchString(trimmed) || strings.HasPrefix(trimmed, "-----Original Message-----") {
+			break
+		}
+		if strings.HasPrefix(trimmed, ">") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func collectText(w io.Writer, header textproto.MIMEHeader, body io.Reader) error {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType = "text/plain"
+	}
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("Failed to read message part: %w", err)
+			}
+			err = collectText(w, part.Header, part)
+			if err != nil {
+				return err
+			}
+			if mediaType == "multipart/alternative" {
+				return nil
+			}
+		}
+	}
+	if mediaType != "text/plain" {
+		return nil
+	}
+	_, err = io.Copy(w, decodeBody(header, body))
+	return err
+}
+
+// parseSignups extracts signups from a reply. A hospital acronym makes it a
+// hospital signup for the hospital net of the month the message was sent in,
+// otherwise every net date of the city responsibility schedule mentioned in
+// the text is a net control signup. The volunteer is the member whose call
+// sign is mentioned in the text or whose email sent the message.
+func parseSignups(msg *mail.Message, callsignDB map[string]Member, citySchedule []CityResponsibilityRecord, hospitalDir string, now time.Time) ([]Signup, error) {
+	sent, err := msg.Header.Date()
+	if err != nil {
+		sent = now
+	}
+	sent = sent.In(now.Location())
+	source := fmt.Sprintf("%v: %v", msg.Header.Get("From"), msg.Header.Get("Subject"))
+	text, err := messageText(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	var callsigns []string
+	var unknownCallsigns []string
+	var hospitals []string
+	for _, word := range strings.FieldsFunc(strings.ToUpper(text), func(r rune) bool {
+		return !('A' <= r && r <= 'Z' || '0' <= r && r <= '9')
+	}) {
+		if _, ok := callsignDB[word]; ok {
+			callsigns = appendUnique(callsigns, word)
+			continue
+		}
+		for _, h := range Hospitals {
+			if word == h.Acronym {
+				hospitals = appendUnique(hospitals, word)
+			}
+		}
+		if len(word) >= 4 && len(word) <= 7 && strings.ContainsAny(word, "0123456789") && signupCallsignPattern.MatchString(word) {
+			unknownCallsigns = appendUnique(unknownCallsigns, word)
+		}
+	}
+	var member Member
+	switch {
+	case len(callsigns) == 1:
+		member = callsignDB[callsigns[0]]
+	case len(callsigns) > 1:
+		return nil, fmt.Errorf("More than one call sign in %v: %v", source, strings.Join(callsigns, ", "))
+	default:
+		from, err := mail.ParseAddress(msg.Header.Get("From"))
+		if err == nil {
+			// Family members may share an email address.
+			matches := make([]string, 0)
+			for _, m := range callsignDB {
+				if m.Email != "" && strings.EqualFold(m.Email, from.Address) {
+					member = m
+					matches = appendUnique(matches, m.Callsign)
+				}
+			}
+			if len(matches) > 1 {
+				sort.Strings(matches)
+				return nil, fmt.Errorf("More than one member with email %v in %v: %v", from.Address, source, strings.Join(matches, ", "))
+			}
+		}
+		if member.Callsign == "" && len(unknownCallsigns) > 0 {
+			return nil, fmt.Errorf("Unknown call sign in %v: %v", source, strings.Join(unknownCallsigns, ", "))
+		}
+		if member.Callsign == "" {
+			return nil, fmt.Errorf("No call sign in %v", source)
+		}
+	}
+
+	signups := make([]Signup, 0)
+	if len(hospitals) > 0 {
+		netDate, err := hospitalNetDate(monthPrefixString(sent), hospitalDir)
+		if err != nil {
+			return nil, err
+		}
+		for _, h := range hospitals {
+			signups = append(signups, Signup{Member: member, Date: netDate, Hospital: h, Source: source})
+		}
+		return signups, nil
+	}
+	for _, match := range signupDatePattern.FindAllStringSubmatch(text, -1) {
+		date, ok := signupDate(match, sent)
+		if !ok {
+			continue
+		}
+		netDay := false
+		for _, cr := range citySchedule {
+			if equalByDate(cr.Date, date) {
+				netDay = true
+			}
+		}
+		if !netDay {
+			fmt.Printf("Ignoring %v from %v: no net on this date\n", date.Format("1/2/2006"), source)
+			continue
+		}
+		signups = append(signups, Signup{Member: member, Date: date, Source: source})
+	}
+	if len(signups) == 0 {
+		return nil, fmt.Errorf("No net date or hospital in %v", source)
+	}
+	return signups, nil
+}
+
+// signupDate converts a M/D or M/D/YYYY match to a date. Without a year the
+// date is assumed to be near the time the message was sent.
+func signupDate(match []string, sent time.Time) (time.Time, bool) {
+	month, _ := strconv.Atoi(match[1])
+	day, _ := strconv.Atoi(match[2])
+	if month < 1 || month > 12 || day < 1 || day > 31 {
+		return time.Time{}, false
+	}
+	year := sent.Year()
+	if match[3] != "" {
+		year, _ = strconv.Atoi(match[3])
+		if year < 100 {
+			year += 2000
+		}
+	}
+	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, sent.Location())
+	if match[3] == "" && date.Before(sent.AddDate(0, -1, 0)) {
+		date = date.AddDate(1, 0, 0)
+	}
+	return date, true
+}
+
+func appendUnique(l []string, s string) []string {
+	for _, v := range l {
+		if v == s {
+			return l
+		}
+	}
+	return append(l, s)
+}
+
+// ingestSignups reads replies to signup announcements from the mailbox and
+// prints schedule additions. With apply the additions are written to the net
+// control schedule and the hospital net logs unless the resulting schedule
+// has unacknowledged conflicts.
+func ingestSignups(mailbox string, apply bool, ackConflicts bool, config *Config, callsignDB map[string]Member, now time.Time) error {
+	messages, err := readMailbox(mailbox)
+	if err != nil {
+		return err
+	}
+	citySchedule, err := readCityResponsibilitySchedule()
+	if err != nil {
+		return fmt.Errorf("Failed to read city responsibility schedule: %w", err)
+	}
+	ncSchedule, err := readNetcontrolSchedule()
+	if err != nil {
+		return fmt.Errorf("Failed to parse net control schedule: %w", err)
+	}
+	hospitalLogs := make(map[string][]HospitalAssignment)
+	months := make([]string, 0)
+
+	additions := make([]Signup, 0)
+	for _, msg := range messages {
+		signups, err := parseSignups(msg, callsignDB, citySchedule, config.HospitalDir, now)
+		if err != nil {
+			fmt.Printf("Skipping message. %v\n", err)
+			continue
+		}
+		for _, s := range signups {
+			monthPrefix := monthPrefixString(s.Date)
+			if s.Hospital != "" {
+				assignments, ok := hospitalLogs[monthPrefix]
+				if !ok {
+					assignments = make([]HospitalAssignment, 0)
+					if logFileName := hospitalLogFileName(s.Date, config.HospitalDir); fileExists(logFileName) {
+						assignments, err = readHospitalLog(logFileName, callsignDB)
+						if err != nil {
+							return fmt.Errorf("Failed to read hospital log: %w", err)
+						}
+					}
+				}
+				taken := false
+				for _, a := range assignments {
+					if a.Acronym == s.Hospital {
+						if a.Member.Callsign != s.Member.Callsign {
+							fmt.Printf("Not adding %v from %v: hospital is taken by %v\n", s, s.Source, a.Member.Callsign)
+						}
+						taken = true
+					}
+				}
+				if taken {
+					continue
+				}
+				hospitalLogs[monthPrefix] = append(assignments, HospitalAssignment{HospitalDescriptor{Acronym: s.Hospital}, s.Member})
+			} else {
+				taken := false
+				for _, nr := range ncSchedule {
+					if equalByDate(nr.Date, s.Date) {
+						if !strings.EqualFold(nr.Callsign, s.Member.Callsign) {
+							fmt.Printf("Not adding %v from %v: net is taken by %v\n", s, s.Source, nr.Callsign)
+						}
+						taken = true
+					}
+				}
+				if taken {
+					continue
+				}
+				ncSchedule = append(ncSchedule, NetcontrolScheduleRecord{s.Date, s.Member.Callsign, ConfirmationPending, ""})
+			}
+			fmt.Printf("Adding %v\n", s)
+			additions = append(additions, s)
+			months = appendUnique(months, monthPrefix)
+		}
+	}
+	conflicts := make([]string, 0)
+	for _, monthPrefix := range months {
+		c, err := scheduleConflicts(monthPrefix, ncSchedule, "", callsignDB)
+		if err != nil {
+			return err
+		}
+		conflicts = append(conflicts, c...)
+		if assignments, ok := hospitalLogs[monthPrefix]; ok {
+			netDate, err := hospitalNetDate(monthPrefix, config.HospitalDir)
+			if err != nil {
+				return err
+			}
+			conflicts = append(conflicts, hospitalConflicts(netDate, assignments)...)
+		}
+	}
+	if !apply {
+		for _, c := range conflicts {
+			fmt.Printf("Schedule conflict: %v\n", c)
+		}
+		if len(additions) > 0 {
+			fmt.Printf("Use -apply to add %v signups to the schedule.\n", len(additions))
+		}
+		return nil
+	}
+	err = checkConflicts(conflicts, ackConflicts)
+	if err != nil {
+		return err
+	}
+	for _, s := range additions {
+		if s.Hospital != "" {
+			err = appendLine(hospitalLogFileName(s.Date, config.HospitalDir), fmt.Sprintf("%v %v", s.Hospital, s.Member.Callsign))
+		} else {
+			err = appendLine(configPath(NetcontrolScheduleFileName, NetcontrolScheduleFileName), fmt.Sprintf("%v\t%v", s.Date.Format("1/2/2006"), s.Member.Callsign))
+		}
+		if err != nil {
+			return fmt.Errorf("Failed to add %v: %w", s, err)
+		}
+	}
+	return nil
+}
+
+// hospitalLogFileName returns the existing hospital net log of the net's
+// month or the name of a new log named after the net date.
+func hospitalLogFileName(netDate time.Time, logDirectory string) string {
+	list, _ := filepath.Glob(filepath.Join(logDirectory, monthPrefixString(netDate)) + "*")
+	if len(list) > 0 {
+		return list[0]
+	}
+	return filepath.Join(logDirectory, netDateString(netDate)+".txt")
+}
+
+// appendLine appends the line to the file making sure it starts on a new
+// line.
+func appendLine(fileName string, line string) error {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		line = "\n" + line
+	}
+	f, err := os.OpenFile(fileName, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.WriteString(line + "\n")
+	return err
+}
+
+func fileExists(fileName string) bool {
+	_, err := os.Stat(fileName)
+	return err == nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testMbox = `From herman@munster.com Mon Sep 26 10:00:00 2022
+From: Herman <herman@munster.com>
+Subject: Re: Net control signups
+Date: Mon, 26 Sep 2022 10:00:00 -0700
+
+I'll take 10/18. K4LXF4
+
+On Sun, Sep 25, 2022 at 9:00 AM Victor wrote:
+> 10/4/2022	San Jose	K4LXF4
+> 10/11/2022	Campbell	N6DVS
+
+From someone@example.com Mon Sep 26 11:00:00 2022
+From: Someone <someone@example.com>
+Subject: Re: Net control signups
+Date: Mon, 26 Sep 2022 11:00:00 -0700
+
+>From now on put me on 10/25, KJ6ABC.
+`
+
+func testSignupCallsigns() map[string]Member {
+	return map[string]Member{
+		"K4LXF4": Member{"Herman", "K4LXF4", "herman@munster.com"},
+		"N6DVS":  Member{"Victor", "N6DVS", "victor@example.com"},
+		"KJ6ABC": Member{"Lily", "KJ6ABC", "lily@munster.com"},
+	}
+}
+
+func testCitySchedule() []CityResponsibilityRecord {
+	var r []CityResponsibilityRecord
+	for _, day := range []int{4, 11, 18, 25} {
+		r = append(r, CityResponsibilityRecord{time.Date(2022, 10, day, 0, 0, 0, 0, time.Local), "San Jose"})
+	}
+	return r
+}
+
+func TestReadMbox(t *testing.T) {
+	messages, err := readMbox(strings.NewReader(testMbox))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(messages))
+	text, err := messageText(messages[1])
+	assert.Nil(t, err)
+	assert.Equal(t, "From now on put me on 10/25, KJ6ABC.\n", text)
+}
+
+func TestParseSignups(t *testing.T) {
+	messages, err := readMbox(strings.NewReader(testMbox))
+	assert.Nil(t, err)
+	now := time.Date(2022, 9, 27, 0, 0, 0, 0, time.Local)
+
+	signups, err := parseSignups(messages[0], testSignupCallsigns(), testCitySchedule(), t.TempDir(), now)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(signups))
+	assert.Equal(t, "K4LXF4", signups[0].Member.Callsign)
+	assert.Equal(t, time.Date(2022, 10, 18, 0, 0, 0, 0, time.Local), signups[0].Date)
+
+	signups, err = parseSignups(messages[1], testSignupCallsigns(), testCitySchedule(), t.TempDir(), now)
+	assert.Nil(t, err)
+	assert.Equal(t, "KJ6ABC", signups[0].Member.Callsign)
+	assert.Equal(t, time.Date(2022, 10, 25, 0, 0, 0, 0, time.Local), signups[0].Date)
+}
+
+func TestParseHospitalSignupFromSender(t *testing.T) {
+	messages, err := readMbox(strings.NewReader(`From herman@munster.com Mon Oct 17 10:00:00 2022
+From: Herman <herman@munster.com>
+Subject: Re: [SJ-RACES] Hospital Net next Wednesday, 7pm
+Date: Mon, 17 Oct 2022 10:00:00 -0700
+
+I can do GSH.
+`))
+	assert.Nil(t, err)
+	now := time.Date(2022, 10, 18, 0, 0, 0, 0, time.Local)
+	signups, err := parseSignups(messages[0], testSignupCallsigns(), testCitySchedule(), t.TempDir(), now)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(signups))
+	assert.Equal(t, "GSH", signups[0].Hospital)
+	assert.Equal(t, "K4LXF4", signups[0].Member.Callsign)
+	assert.Equal(t, time.Date(2022, 10, 26, 0, 0, 0, 0, time.Local), signups[0].Date)
+}
+
+const testHospitalSignupFromSender = `From herman@munster.com Mon Oct 17 10:00:00 2022
+From: Herman <herman@munster.com>
+Subject: Re: [SJ-RACES] Hospital Net next Wednesday, 7pm
+Date: Mon, 17 Oct 2022 10:00:00 -0700
+
+I can do GSH.
+`
+
+func TestParseSignupsSharedSenderEmail(t *testing.T) {
+	messages, err := readMbox(strings.NewReader(testHospitalSignupFromSender))
+	assert.Nil(t, err)
+	callsigns := testSignupCallsigns()
+	callsigns["KJ6ABC"] = Member{"Lily", "KJ6ABC", "herman@munster.com"}
+	now := time.Date(2022, 10, 18, 0, 0, 0, 0, time.Local)
+	_, err = parseSignups(messages[0], callsigns, testCitySchedule(), t.TempDir(), now)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "More than one member with email herman@munster.com")
+	assert.Contains(t, err.Error(), "K4LXF4, KJ6ABC")
+
+	// A tactical call sign of the member is not another member.
+	messages, err = readMbox(strings.NewReader(testHospitalSignupFromSender))
+	assert.Nil(t, err)
+	callsigns = testSignupCallsigns()
+	callsigns["NET1"] = callsigns["K4LXF4"]
+	signups, err := parseSignups(messages[0], callsigns, testCitySchedule(), t.TempDir(), now)
+	assert.Nil(t, err)
+	assert.Equal(t, "K4LXF4", signups[0].Member.Callsign)
+}
+
+func TestParseSignupsUnknownCallsign(t *testing.T) {
+	messages, err := readMbox(strings.NewReader(`From x@example.com Mon Sep 26 10:00:00 2022
+From: x@example.com
+Date: Mon, 26 Sep 2022 10:00:00 -0700
+
+W1XYZ for 10/18
+`))
+	assert.Nil(t, err)
+	_, err = parseSignups(messages[0], testSignupCallsigns(), testCitySchedule(), t.TempDir(), time.Now())
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "Unknown call sign")
+}
+
+func TestIngestSignupsApply(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	configDir := filepath.Join(home, ".net-manager")
+	assert.Nil(t, os.MkdirAll(configDir, 0755))
+	err := ioutil.WriteFile(filepath.Join(configDir, CityResponsiblityScheduleFileName), []byte("10/4/2022\tSan Jose\n10/11/2022\tCampbell\n10/18/2022\tSan Jose\n10/25/2022\tSaratoga\n"), 0644)
+	assert.Nil(t, err)
+	err = ioutil.WriteFile(filepath.Join(configDir, NetcontrolScheduleFileName), []byte("10/4/2022\tK4LXF4\n10/25/2022\tN6DVS"), 0644)
+	assert.Nil(t, err)
+	mbox := filepath.Join(t.TempDir(), "signups.mbox")
+	assert.Nil(t, ioutil.WriteFile(mbox, []byte(testMbox), 0644))
+	config := &Config{HospitalDir: t.TempDir()}
+	now := time.Date(2022, 9, 27, 0, 0, 0, 0, time.Local)
+
+	err = ingestSignups(mbox, false, false, config, testSignupCallsigns(), now)
+	assert.Nil(t, err)
+	data, err := ioutil.ReadFile(filepath.Join(configDir, NetcontrolScheduleFileName))
+	assert.Nil(t, err)
+	assert.Equal(t, "10/4/2022\tK4LXF4\n10/25/2022\tN6DVS", string(data))
+
+	err = ingestSignups(mbox, true, false, config, testSignupCallsigns(), now)
+	assert.Nil(t, err)
+	data, err = ioutil.ReadFile(filepath.Join(configDir, NetcontrolScheduleFileName))
+	assert.Nil(t, err)
+	assert.Equal(t, "10/4/2022\tK4LXF4\n10/25/2022\tN6DVS\n10/18/2022\tK4LXF4\n", string(data))
+}
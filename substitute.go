@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gopkg.in/gomail.v2"
+)
+
+// substituteWindow is how long before the net an empty slot needs a
+// substitute.
+const substituteWindow = 7 * 24 * time.Hour
+
+// openSlot reports whether nobody is going to run the net on the date. A net
+// is open if nobody signed up for it or the net control declined and there is
+// no alternate. The net control who declined is returned too.
+func openSlot(netDate time.Time, ncSchedule []NetcontrolScheduleRecord) (bool, NetcontrolScheduleRecord) {
+	var record NetcontrolScheduleRecord
+	for _, nr := range ncSchedule {
+		if equalByDate(nr.Date, netDate) {
+			record = nr
+		}
+	}
+	if record.Callsign == "" {
+		return true, record
+	}
+	return record.Confirmation == ConfirmationDeclined && record.Alternate == "", record
+}
+
+// requestSubstitute emails the substitute pool if the net on netDate is open.
+func requestSubstitute(netDate time.Time, callsignDB map[string]Member, config *Config, mailer Mailer, ncSchedule []NetcontrolScheduleRecord, citySchedule []CityResponsibilityRecord) error {
+	netDay := false
+	for _, cr := range citySchedule {
+		if equalByDate(cr.Date, netDate) {
+			netDay = true
+		}
+	}
+	if !netDay {
+		return nil
+	}
+	open, record := openSlot(netDate, ncSchedule)
+	if !open {
+		return nil
+	}
+	declined := callsignDB[strings.ToUpper(record.Callsign)]
+	if declined.Callsign == "" {
+		declined.Callsign = strings.ToUpper(record.Callsign)
+	}
+	var to []string
+	for _, callsign := range config.SubstitutePool {
+		callsign = strings.ToUpper(strings.TrimSpace(callsign))
+		if callsign == declined.Callsign {
+			continue
+		}
+		member, ok := callsignDB[callsign]
+		if !ok || member.Email == "" {
+			fmt.Printf("Skipping %v from substitute pool: no email\n", callsign)
+			continue
+		}
+		to = append(to, member.Email)
+	}
+	if len(to) == 0 {
+		return fmt.Errorf("Substitute pool is empty")
+	}
+
+	m := gomail.NewMessage()
+	m.SetHeader("From", config.Station.Mail.Email)
+	m.SetHeader("To", to...)
+	m.SetHeader("Bcc", config.Station.Mail.Email)
+	m.SetHeader("Subject", fmt.Sprintf("Substitute net control needed %v", netDate.Format("1/2/2006")))
+	err := setBody(m, SubstituteRequestKind, MessageData{
+		Station: config.Station,
+		Month:   time.Date(netDate.Year(), netDate.Month(), 1, 0, 0, 0, 0, netDate.Location()),
+		Member:  declined,
+		NetDate: netDate,
+	})
+	if err != nil {
+		return err
+	}
+	if err := mailer.Send(m); err != nil {
+		return fmt.Errorf("Failed to send email: %w", err)
+	}
+	return nil
+}
+
+// requestSubstitutes asks the substitute pool to run every open net within
+// the next week. Requests recorded in the ledger are not sent again unless
+// force is set.
+func requestSubstitutes(now time.Time, callsignDB map[string]Member, config *Config, mailer Mailer, ledger *Ledger, force bool) error {
+	ncSchedule, err := readNetcontrolSchedule()
+	if err != nil {
+		return fmt.Errorf("Failed to parse net control schedule: %w", err)
+	}
+	citySchedule, err := readCityResponsibilitySchedule()
+	if err != nil {
+		return fmt.Errorf("Failed to read city responsibility schedule: %w", err)
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	for _, cr := range citySchedule {
+		netDate := time.Date(cr.Date.Year(), cr.Date.Month(), cr.Date.Day(), 0, 0, 0, 0, now.Location())
+		if netDate.Before(today) || netDate.Sub(today) > substituteWindow {
+			continue
+		}
+		if open, _ := openSlot(netDate, ncSchedule); !open {
+			continue
+		}
+		period := netDateString(netDate)
+		if !shouldSend(ledger, SubstituteRequestKind, period, force) {
+			continue
+		}
+		err = requestSubstitute(netDate, callsignDB, config, &LedgerMailer{mailer, ledger, SubstituteRequestKind, period}, ncSchedule, citySchedule)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenSlot(t *testing.T) {
+	netDate := time.Date(2022, 10, 11, 0, 0, 0, 0, time.Local)
+	open, _ := openSlot(netDate, nil)
+	assert.True(t, open)
+
+	schedule := []NetcontrolScheduleRecord{{Date: netDate, Callsign: "K4LXF4", Confirmation: ConfirmationPending}}
+	open, _ = openSlot(netDate, schedule)
+	assert.False(t, open)
+
+	schedule[0].Confirmation = ConfirmationDeclined
+	open, record := openSlot(netDate, schedule)
+	assert.True(t, open)
+	assert.Equal(t, "K4LXF4", record.Callsign)
+
+	schedule[0].Alternate = "N6DVS"
+	open, _ = openSlot(netDate, schedule)
+	assert.False(t, open)
+}
+
+func TestRequestSubstitute(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	callsigns := testSignupCallsigns()
+	config := &Config{SubstitutePool: []string{"k4lxf4", "N6DVS", "KJ6ABC"}}
+	netDate := time.Date(2022, 10, 11, 0, 0, 0, 0, time.Local)
+	schedule := []NetcontrolScheduleRecord{{Date: netDate, Callsign: "K4LXF4", Confirmation: ConfirmationDeclined}}
+	mailer := &RecordingMailer{}
+
+	err := requestSubstitute(netDate, callsigns, config, mailer, schedule, testCitySchedule())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(mailer.Messages))
+	assert.Equal(t, []string{"victor@example.com", "lily@munster.com"}, mailer.Messages[0].GetHeader("To"))
+	assert.Equal(t, []string{"Substitute net control needed 10/11/2022"}, mailer.Messages[0].GetHeader("Subject"))
+
+	schedule[0].Confirmation = ConfirmationConfirmed
+	err = requestSubstitute(netDate, callsigns, config, mailer, schedule, testCitySchedule())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(mailer.Messages))
+}
+
+func TestNotifyNetControlAlternate(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	callsigns := testSignupCallsigns()
+	schedule := []NetcontrolScheduleRecord{
+		{Date: time.Date(2022, 10, 11, 0, 0, 0, 0, time.Local), Callsign: "K4LXF4", Alternate: "N6DVS"},
+	}
+	mailer := &RecordingMailer{}
+
+	err := notifyNetControl(time.Date(2022, 10, 9, 0, 0, 0, 0, time.Local), callsigns, &Config{}, mailer, schedule, "")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(mailer.Messages))
+	assert.Equal(t, []string{"herman@munster.com"}, mailer.Messages[0].GetHeader("To"))
+	assert.Equal(t, []string{"victor@example.com"}, mailer.Messages[0].GetHeader("Cc"))
+	var buf bytes.Buffer
+	assert.Nil(t, writeMessage(&buf, mailer.Messages[0]))
+	assert.Contains(t, buf.String(), "Victor N6DVS is the alternate net control")
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"sort"
+)
+
+// maxSuggestionDistance is the largest edit distance of a suggested call
+// sign.
+const maxSuggestionDistance = 1
+
+// editDistance returns the optimal string alignment distance: the number of
+// insertions, deletions, substitutions and transpositions of adjacent
+// characters that turn a into b.
+func editDistance(a, b string) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min3(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] && d[i-2][j-2]+1 < d[i][j] {
+				d[i][j] = d[i-2][j-2] + 1
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// suggestCallsigns returns member call signs that the unknown call sign was
+// probably meant to be, closest first.
+func suggestCallsigns(callsign string, callSigns map[string]Member) []string {
+	base := normalizeCallsign(callsign)
+	distances := make(map[string]int)
+	for c, m := range callSigns {
+		if c != m.Callsign {
+			// Tactical call signs are not suggested.
+			continue
+		}
+		distance := editDistance(callsign, c)
+		if base != callsign {
+			// The suffix is not a typo.
+			if d := editDistance(base, c); d < distance {
+				distance = d
+			}
+		}
+		if distance <= maxSuggestionDistance {
+			distances[c] = distance
+		}
+	}
+	res := make([]string, 0, len(distances))
+	for c := range distances {
+		res = append(res, c)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if distances[res[i]] != distances[res[j]] {
+			return distances[res[i]] < distances[res[j]]
+		}
+		return res[i] < res[j]
+	})
+	return res
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEditDistance(t *testing.T) {
+	assert.Equal(t, 0, editDistance("KJ6ABC", "KJ6ABC"))
+	assert.Equal(t, 1, editDistance("KJ6ABS", "KJ6ABC"))
+	assert.Equal(t, 1, editDistance("KJ6BAC", "KJ6ABC"))
+	assert.Equal(t, 1, editDistance("KJ6AB", "KJ6ABC"))
+	assert.Equal(t, 3, editDistance("", "N6D"))
+}
+
+func TestSuggestCallsigns(t *testing.T) {
+	callsigns := testSignupCallsigns()
+	assert.Equal(t, []string{"KJ6ABC"}, suggestCallsigns("KJ6ABS", callsigns))
+	assert.Equal(t, []string{"KJ6ABC"}, suggestCallsigns("KJ6BAC", callsigns))
+	assert.Equal(t, []string{"N6DVS"}, suggestCallsigns("N6DVS/M", callsigns))
+	assert.Equal(t, []string{"N6DVS"}, suggestCallsigns("N6DVX/P", callsigns))
+	assert.Equal(t, []string{}, suggestCallsigns("W1XYZ", callsigns))
+}
+
+func TestAcceptSuggestions(t *testing.T) {
+	callsigns := testSignupCallsigns()
+	callsigns["KJ6ABD"] = Member{"Eddie", "KJ6ABD", ""}
+	annotator := newCheckinAnnotator(callsigns)
+	annotator.acceptSuggestions = true
+
+	item := annotator.annotate(parseCheckinLine("N6DVX"))
+	assert.Equal(t, &MemberCheckin{"N6DVS", CheckinRecord{Callsign: "N6DVX"}}, item)
+	item = annotator.annotate(parseCheckinLine("KJ6ABS"))
+	assert.Equal(t, &UnknownCheckin{"KJ6ABS", CheckinRecord{Callsign: "KJ6ABS"}, []string{"KJ6ABC", "KJ6ABD"}}, item)
+}
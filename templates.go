@@ -0,0 +1,316 @@
+package main
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"io/ioutil"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"gopkg.in/gomail.v2"
+)
+
+// MessageData is available to all email templates. Fields that are not
+// relevant for a message are left empty.
+type MessageData struct {
+	// Station is the station from the configuration file. Its Call and
+	// Signature are most useful in templates.
+	Station Station
+	// Month is the first day of the month the message is about.
+	Month time.Time
+	// LateNote explains that the message is sent later than it was due.
+	// It's empty for messages sent on time.
+	LateNote string
+	// Schedule is the net control schedule of the month for net signups.
+	Schedule []ScheduleRecord
+	// Hospitals are hospital net assignments for hospital signups.
+	Hospitals []HospitalAssignment
+	// HospitalNameWidth is the length of the longest hospital name.
+	HospitalNameWidth int
+	// TimeSheet is the net time sheet of the month for the report.
+	TimeSheet string
+	// TimeSheetRows are lines of the time sheet, one per net log.
+	TimeSheetRows []TimeSheetRow
+	// NetHours, HospitalHours and TotalHours are volunteer hours for the
+	// report.
+	NetHours      float64
+	HospitalHours float64
+	TotalHours    float64
+	// HospitalMethod tells how hospital hours were computed.
+	HospitalMethod string
+	// HoursLegend describes how hours of every net type are computed.
+	HoursLegend []string
+	// Member is the recipient of personal messages like net control alerts.
+	// For substitute requests it's the net control who declined.
+	Member Member
+	// Alternate is the alternate net control for net control alerts.
+	Alternate Member
+	// NetDate is the date of the net the net control alert or the hospital
+	// alert is about.
+	NetDate time.Time
+	// Hospital is the hospital assigned to the Member for hospital alerts.
+	Hospital HospitalDescriptor
+	// Confirmation is the confirmation state of the net control for
+	// escalations.
+	Confirmation string
+	// DaysLeft is the number of days before the net for reminders.
+	DaysLeft int
+}
+
+// HospitalAssignment is a hospital with its operator. Member is empty if the
+// hospital is available.
+type HospitalAssignment struct {
+	HospitalDescriptor
+	Member Member
+}
+
+const (
+	templateExtension     = ".tmpl"
+	htmlTemplateExtension = ".html.tmpl"
+)
+
+var defaultTemplates = map[string]string{
+	HospitalSignupsKind: `Hi folks,
+
+Hospital net is next week.
+Please sign up for one of the hospitals.
+In order to sign up you need to reply to this email with your callsign and the hospital of choice.
+
+{{range .Hospitals}}{{pad .FullName (add $.HospitalNameWidth 10)}}{{if .Member.Callsign}}{{.Member.Callsign}}{{else}}Available!{{end}}
+{{end}}
+Net control is Regional San Jose (RSJ)
+
+
+
+{{.Station.Signature}}`,
+	NetSignupsKind: `Hi,
+
+Net control positions are open.
+
+Here is the schedule right now:
+{{range .Schedule}}{{.Date.Format "1/2/2006"}}	{{.City}}	{{.Callsign}}
+{{end}}
+Simply respond to this email with your name, callsign and date to signup for a net control position.
+
+
+{{.Station.Signature}}`,
+	ReportKind: `Hi folks,
+
+{{.LateNote}}Here is net control statistics for {{.Month.Format "Jan 2006"}}:
+
+{{.TimeSheet}}
+Hospital Net: {{printf "%0.3f" .HospitalHours}}{{if .HospitalMethod}}	{{.HospitalMethod}}{{end}}
+
+Total Hours: {{printf "%0.3f" .TotalHours}}
+{{if .HoursLegend}}
+Hours are computed as follows:
+{{range .HoursLegend}}{{.}}
+{{end}}{{end}}
+
+{{.Station.Signature}}`,
+	NetControlAlertKind: `Hi {{.Member.Name}},
+
+{{.LateNote}}Thank you for volunteering. Could you please confirm that you are still comfortable running the net on {{.NetDate.Format "1/2/2006"}}
+{{if .Alternate.Callsign}}
+{{.Alternate.Name}} {{.Alternate.Callsign}} is the alternate net control for this net.
+{{end}}
+Thanks, Victor.`,
+	NetControlEscalationKind: `Hi,
+
+Net control {{.Member.Name}} {{.Member.Callsign}} {{if eq .Confirmation "declined"}}declined{{else}}hasn't confirmed{{end}} the net on {{.NetDate.Format "1/2/2006"}}.
+Please find a backup net control.
+
+
+{{.Station.Signature}}`,
+	NetControlReminderKind: `Hi {{.Member.Name}},
+
+{{if .DaysLeft}}This is a reminder that you are running the net in {{.DaysLeft}} days on {{.NetDate.Format "1/2/2006"}}.{{else}}This is a reminder that you are running the net today.{{end}}
+{{if .Alternate.Callsign}}{{.Alternate.Name}} {{.Alternate.Callsign}} is the alternate net control for this net.
+{{end}}
+Thanks, Victor.`,
+	SubstituteRequestKind: `Hi,
+
+{{if .Member.Callsign}}Net control {{.Member.Callsign}} can't run the net on {{.NetDate.Format "1/2/2006"}}{{else}}Nobody signed up to run the net on {{.NetDate.Format "1/2/2006"}}{{end}}.
+Could you run it instead? Please reply to this email if you can.
+
+
+{{.Station.Signature}}`,
+	HospitalAlertKind: `Hi {{.Member.Name}},
+
+Thank you for signing up for {{.Hospital.FullName}} ({{.Hospital.Acronym}}) on the hospital net on {{.NetDate.Format "1/2/2006"}}.
+
+
+{{.Station.Signature}}`,
+}
+
+const htmlHeader = `<html>
+<head>
+<style>
+table { border-collapse: collapse; }
+th, td { border: 1px solid #999; padding: 4px 8px; text-align: left; }
+</style>
+</head>
+<body>
+`
+
+const htmlFooter = `
+</body>
+</html>
+`
+
+var defaultHTMLTemplates = map[string]string{
+	HospitalSignupsKind: htmlHeader + `<p>Hi folks,</p>
+<p>Hospital net is next week.<br>
+Please sign up for one of the hospitals.<br>
+In order to sign up you need to reply to this email with your callsign and the hospital of choice.</p>
+<table>
+<tr><th>Hospital</th><th>Operator</th></tr>
+{{range .Hospitals}}<tr><td>{{.FullName}}</td><td>{{if .Member.Callsign}}{{.Member.Callsign}}{{else}}<b>Available!</b>{{end}}</td></tr>
+{{end}}</table>
+<p>Net control is Regional San Jose (RSJ)</p>
+<p>{{.Station.Signature}}</p>` + htmlFooter,
+	NetSignupsKind: htmlHeader + `<p>Hi,</p>
+<p>Net control positions are open.</p>
+<p>Here is the schedule right now:</p>
+<table>
+<tr><th>Date</th><th>City</th><th>Net Control</th></tr>
+{{range .Schedule}}<tr><td>{{.Date.Format "1/2/2006"}}</td><td>{{.City}}</td><td>{{if .Callsign}}{{.Callsign}}{{else}}<b>Open</b>{{end}}</td></tr>
+{{end}}</table>
+<p>Simply respond to this email with your name, callsign and date to signup for a net control position.</p>
+<p>{{.Station.Signature}}</p>` + htmlFooter,
+	ReportKind: htmlHeader + `<p>Hi folks,</p>
+{{if .LateNote}}<p>{{.LateNote}}</p>
+{{end}}<p>Here is net control statistics for {{.Month.Format "Jan 2006"}}:</p>
+<table>
+<tr><th>Net Log</th><th>Checkins</th><th>Hours</th><th>Preparation</th><th>Reporting</th><th>Total</th><th>Method</th></tr>
+{{range .TimeSheetRows}}<tr><td>{{.FileName}}</td><td>{{.Checkins}}</td><td>{{printf "%0.3f" .Time}}</td><td>{{printf "%0.3f" .Prep}}</td><td>{{printf "%0.3f" .Reporting}}</td><td>{{printf "%0.3f" .Hours}}</td><td>{{.Method}}</td></tr>
+{{end}}<tr><th colspan="5">Net Total</th><th>{{printf "%0.3f" .NetHours}}</th><th></th></tr>
+<tr><th colspan="5">Hospital Net</th><th>{{printf "%0.3f" .HospitalHours}}</th><th>{{.HospitalMethod}}</th></tr>
+<tr><th colspan="5">Total Hours</th><th>{{printf "%0.3f" .TotalHours}}</th><th></th></tr>
+</table>
+{{if .HoursLegend}}<p>Hours are computed as follows:</p>
+<ul>
+{{range .HoursLegend}}<li>{{.}}</li>
+{{end}}</ul>
+{{end}}<p>{{.Station.Signature}}</p>` + htmlFooter,
+	NetControlAlertKind: htmlHeader + `<p>Hi {{.Member.Name}},</p>
+{{if .LateNote}}<p>{{.LateNote}}</p>
+{{end}}<p>Thank you for volunteering. Could you please confirm that you are still comfortable running the net on {{.NetDate.Format "1/2/2006"}}</p>
+{{if .Alternate.Callsign}}<p>{{.Alternate.Name}} {{.Alternate.Callsign}} is the alternate net control for this net.</p>
+{{end}}<p>Thanks, Victor.</p>` + htmlFooter,
+	NetControlEscalationKind: htmlHeader + `<p>Hi,</p>
+<p>Net control {{.Member.Name}} {{.Member.Callsign}} {{if eq .Confirmation "declined"}}<b>declined</b>{{else}}<b>hasn't confirmed</b>{{end}} the net on {{.NetDate.Format "1/2/2006"}}.<br>
+Please find a backup net control.</p>
+<p>{{.Station.Signature}}</p>` + htmlFooter,
+	NetControlReminderKind: htmlHeader + `<p>Hi {{.Member.Name}},</p>
+<p>{{if .DaysLeft}}This is a reminder that you are running the net in {{.DaysLeft}} days on {{.NetDate.Format "1/2/2006"}}.{{else}}This is a reminder that you are running the net today.{{end}}</p>
+{{if .Alternate.Callsign}}<p>{{.Alternate.Name}} {{.Alternate.Callsign}} is the alternate net control for this net.</p>
+{{end}}<p>Thanks, Victor.</p>` + htmlFooter,
+	SubstituteRequestKind: htmlHeader + `<p>Hi,</p>
+<p>{{if .Member.Callsign}}Net control {{.Member.Callsign}} can't run the net on {{.NetDate.Format "1/2/2006"}}{{else}}Nobody signed up to run the net on {{.NetDate.Format "1/2/2006"}}{{end}}.<br>
+Could you run it instead? Please reply to this email if you can.</p>
+<p>{{.Station.Signature}}</p>` + htmlFooter,
+	HospitalAlertKind: htmlHeader + `<p>Hi {{.Member.Name}},</p>
+<p>Thank you for signing up for {{.Hospital.FullName}} ({{.Hospital.Acronym}}) on the hospital net on {{.NetDate.Format "1/2/2006"}}.</p>
+<p>{{.Station.Signature}}</p>` + htmlFooter,
+}
+
+var templateFuncs = template.FuncMap{
+	"pad": func(s string, width int) string {
+		if len(s) >= width {
+			return s
+		}
+		return s + strings.Repeat(" ", width-len(s))
+	},
+	"add": func(a, b int) int {
+		return a + b
+	},
+}
+
+// defaultTemplate returns the built-in template of the kind. All reminder
+// stages share the built-in reminder template.
+func defaultTemplate(templates map[string]string, kind string) (string, bool) {
+	if strings.HasPrefix(kind, NetControlReminderKind+"-") {
+		kind = NetControlReminderKind
+	}
+	text, ok := templates[kind]
+	return text, ok
+}
+
+// templateText returns the contents of the template file in the
+// configuration directory or defaultText if there is no such file.
+func templateText(fileName string, defaultText string) (string, error) {
+	path := configPath(fileName, fileName)
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return defaultText, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("Failed to read template %v: %w", path, err)
+	}
+	return string(data), nil
+}
+
+// renderBody renders the plain text body of the message kind from
+// <kind>.tmpl file in the configuration directory. The built-in template is
+// used if there is no such file.
+func renderBody(kind string, data MessageData) (string, error) {
+	defaultText, ok := defaultTemplate(defaultTemplates, kind)
+	if !ok {
+		return "", fmt.Errorf("Unknown message template: %v", kind)
+	}
+	text, err := templateText(kind+templateExtension, defaultText)
+	if err != nil {
+		return "", err
+	}
+	t, err := template.New(kind).Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("Failed to parse template %v: %w", kind, err)
+	}
+	var sb strings.Builder
+	err = t.Execute(&sb, data)
+	if err != nil {
+		return "", fmt.Errorf("Failed to render template %v: %w", kind, err)
+	}
+	return sb.String(), nil
+}
+
+// renderHTMLBody renders the html body of the message kind from
+// <kind>.html.tmpl file in the configuration directory.
+func renderHTMLBody(kind string, data MessageData) (string, error) {
+	defaultText, ok := defaultTemplate(defaultHTMLTemplates, kind)
+	if !ok {
+		return "", fmt.Errorf("Unknown message template: %v", kind)
+	}
+	text, err := templateText(kind+htmlTemplateExtension, defaultText)
+	if err != nil {
+		return "", err
+	}
+	t, err := htmltemplate.New(kind).Funcs(htmltemplate.FuncMap(templateFuncs)).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("Failed to parse html template %v: %w", kind, err)
+	}
+	var sb strings.Builder
+	err = t.Execute(&sb, data)
+	if err != nil {
+		return "", fmt.Errorf("Failed to render html template %v: %w", kind, err)
+	}
+	return sb.String(), nil
+}
+
+// setBody sets plain text body of the message with html alternative.
+func setBody(m *gomail.Message, kind string, data MessageData) error {
+	text, err := renderBody(kind, data)
+	if err != nil {
+		return err
+	}
+	html, err := renderHTMLBody(kind, data)
+	if err != nil {
+		return err
+	}
+	m.SetBody("text/plain", text)
+	m.AddAlternative("text/html", html)
+	return nil
+}
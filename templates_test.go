@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/gomail.v2"
+)
+
+func TestRenderDefaultNetControlAlert(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	body, err := renderBody(NetControlAlertKind, MessageData{
+		Member:   Member{"Herman", "K4LXF4", "herman@munster.com"},
+		NetDate:  time.Date(2022, 10, 4, 0, 0, 0, 0, time.Local),
+		LateNote: lateNote(time.Date(2022, 10, 3, 0, 0, 0, 0, time.Local), time.Date(2022, 10, 2, 0, 0, 0, 0, time.Local)),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "Hi Herman,\n\nSorry, this email is late. It was due on 10/2/2022.\n\nThank you for volunteering. "+
+		"Could you please confirm that you are still comfortable running the net on 10/4/2022\n\nThanks, Victor.", body)
+}
+
+func TestRenderDefaultHospitalSignups(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	body, err := renderBody(HospitalSignupsKind, MessageData{
+		Station:           Station{Signature: "N6DVS"},
+		HospitalNameWidth: 3,
+		Hospitals: []HospitalAssignment{
+			{HospitalDescriptor{"GSH", "GSH"}, Member{"Herman", "K4LXF4", "herman@munster.com"}},
+			{HospitalDescriptor{"VMC", "VMC"}, Member{}},
+		},
+	})
+	assert.Nil(t, err)
+	assert.Contains(t, body, "\n\nGSH          K4LXF4\nVMC          Available!\n\nNet control")
+	assert.Contains(t, body, "(RSJ)\n\n\n\nN6DVS")
+}
+
+func TestRenderCustomTemplate(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	assert.Nil(t, os.MkdirAll(filepath.Join(home, configDir), 0755))
+	err := ioutil.WriteFile(filepath.Join(home, configDir, ReportKind+templateExtension),
+		[]byte(`Report for {{.Month.Format "January"}}: {{printf "%0.1f" .TotalHours}} hours. {{.Station.Call}}`), 0644)
+	assert.Nil(t, err)
+
+	body, err := renderBody(ReportKind, MessageData{
+		Station:    Station{Call: "N6DVS"},
+		Month:      time.Date(2022, 9, 1, 0, 0, 0, 0, time.Local),
+		TotalHours: 12.25,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "Report for September: 12.2 hours. N6DVS", body)
+}
+
+func TestRenderBrokenTemplate(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	assert.Nil(t, os.MkdirAll(filepath.Join(home, configDir), 0755))
+	err := ioutil.WriteFile(filepath.Join(home, configDir, ReportKind+templateExtension), []byte(`{{.Month`), 0644)
+	assert.Nil(t, err)
+
+	_, err = renderBody(ReportKind, MessageData{})
+	assert.NotNil(t, err)
+}
+
+func TestRenderHTMLReportTable(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	body, err := renderHTMLBody(ReportKind, MessageData{
+		Station:       Station{Signature: "N6DVS <K6 & co>"},
+		Month:         time.Date(2022, 9, 1, 0, 0, 0, 0, time.Local),
+		TimeSheetRows: []TimeSheetRow{{"2022-09-06.txt", 12, 4, 4.75, 0.5, 0.25, EstimateMethod}},
+		NetHours:      4.75,
+		TotalHours:    4.75,
+	})
+	assert.Nil(t, err)
+	assert.Contains(t, body, "<tr><td>2022-09-06.txt</td><td>12</td><td>4.000</td><td>0.500</td><td>0.250</td><td>4.750</td>")
+	assert.Contains(t, body, "<p>N6DVS &lt;K6 &amp; co&gt;</p>")
+}
+
+func TestSetBodyAddsHTMLAlternative(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	m := gomail.NewMessage()
+	err := setBody(m, NetSignupsKind, MessageData{
+		Schedule: []ScheduleRecord{{time.Date(2022, 10, 4, 0, 0, 0, 0, time.Local), "San Jose", ""}},
+	})
+	assert.Nil(t, err)
+
+	var buf bytes.Buffer
+	assert.Nil(t, writeMessage(&buf, m))
+	assert.Contains(t, buf.String(), "Content-Type: multipart/alternative")
+	assert.Contains(t, buf.String(), "Content-Type: text/plain")
+	assert.Contains(t, buf.String(), "Content-Type: text/html")
+}